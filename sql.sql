@@ -171,4 +171,283 @@ CREATE TABLE IF NOT EXISTS transactions (
     description     TEXT,                            -- Mô tả giao dịch
     created_at      TIMESTAMP DEFAULT NOW(),
     updated_at      TIMESTAMP DEFAULT NOW()
-);
\ No newline at end of file
+);
+
+-- Transactional outbox for transaction events (relayed to RabbitMQ by the streaming service)
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    aggregate_type  VARCHAR(50) NOT NULL,            -- vd: 'transaction'
+    aggregate_id    uuid NOT NULL,
+    event_type      VARCHAR(100) NOT NULL,           -- vd: 'transaction.created'
+    routing_key     VARCHAR(255) NOT NULL,
+    payload         JSONB NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    published_at    TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (created_at) WHERE published_at IS NULL;
+
+
+-- Local projection of auth service users, kept up to date from the user_events exchange
+CREATE TABLE IF NOT EXISTS user_projections (
+    id              uuid PRIMARY KEY,
+    email           VARCHAR(255) NOT NULL,
+    name            VARCHAR(255) NOT NULL DEFAULT '',
+    deleted         BOOLEAN NOT NULL DEFAULT FALSE,
+    last_event_at   TIMESTAMP NOT NULL,                -- Thời điểm của event gần nhất đã áp dụng
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE outbox_events
+ADD COLUMN IF NOT EXISTS exchange VARCHAR(255) NOT NULL DEFAULT '';   -- '' = default exchange
+
+ALTER TABLE outbox_events
+ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(255) NOT NULL DEFAULT '';
+
+
+-- Append-only log of transaction events, used to replay missed events to reconnecting stream clients
+CREATE TABLE IF NOT EXISTS transaction_event_log (
+    sequence        BIGSERIAL PRIMARY KEY,             -- Tăng dần theo thứ tự commit
+    event_id        uuid NOT NULL UNIQUE,
+    event_type      VARCHAR(100) NOT NULL,
+    aggregate_id    uuid NOT NULL,
+    user_id         uuid NOT NULL,
+    payload         JSONB NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_transaction_event_log_user ON transaction_event_log (user_id, sequence);
+
+
+-- Change feed for transaction rows modified outside the streaming service (manual SQL, batch jobs)
+CREATE TABLE IF NOT EXISTS transaction_change_feed (
+    id              BIGSERIAL PRIMARY KEY,
+    transaction_id  uuid NOT NULL,
+    operation       VARCHAR(10) NOT NULL,              -- 'INSERT', 'UPDATE'
+    previous_status VARCHAR(20),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    processed_at    TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_transaction_change_feed_pending ON transaction_change_feed (id) WHERE processed_at IS NULL;
+
+-- Runs at commit time, so it can see whether the service already emitted
+-- events for this database transaction (streaming.events_emitted).
+CREATE OR REPLACE FUNCTION record_transaction_change() RETURNS trigger AS $$
+DECLARE
+  change_id BIGINT;
+BEGIN
+  IF coalesce(current_setting('streaming.events_emitted', true), '') = 'on' THEN
+    RETURN NULL;
+  END IF;
+
+  IF TG_OP = 'UPDATE' AND NEW IS NOT DISTINCT FROM OLD THEN
+    RETURN NULL;
+  END IF;
+
+  INSERT INTO transaction_change_feed (transaction_id, operation, previous_status)
+  VALUES (NEW.id, TG_OP, CASE WHEN TG_OP = 'UPDATE' THEN OLD.status END)
+  RETURNING id INTO change_id;
+
+  PERFORM pg_notify('transaction_changes', change_id::text);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_transactions_record_change ON transactions;
+CREATE CONSTRAINT TRIGGER trg_transactions_record_change
+AFTER INSERT OR UPDATE ON transactions
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW EXECUTE FUNCTION record_transaction_change();
+
+
+-- Outbound webhooks: per-user subscriptions, one delivery per subscription and event, one row per HTTP attempt
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id              uuid PRIMARY KEY,
+    user_id         uuid NOT NULL,
+    url             TEXT NOT NULL,
+    event_types     TEXT[] NOT NULL,
+    secret          VARCHAR(100) NOT NULL,
+    active          BOOLEAN NOT NULL DEFAULT TRUE,
+    failure_count   INT NOT NULL DEFAULT 0,                -- consecutive failed attempts
+    disabled_at     TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_user ON webhook_subscriptions (user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              uuid PRIMARY KEY,
+    subscription_id uuid NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id        uuid NOT NULL,
+    event_type      VARCHAR(100) NOT NULL,
+    payload         JSONB NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'succeeded', 'failed'
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_error      TEXT,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (subscription_id, event_id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, created_at);
+-- attempts counts every attempt ever made; retry_base is attempts at the last manual redelivery,
+-- so the retry limit and backoff start over while attempt numbers keep increasing.
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS retry_base INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id              BIGSERIAL PRIMARY KEY,
+    delivery_id     uuid NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+    attempt         INT NOT NULL,
+    status_code     INT,
+    error           TEXT,
+    duration_ms     INT NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts (delivery_id, attempt);
+
+
+-- Double-entry ledger: one wallet account per user and currency, plus system accounts
+-- ('external' for money entering or leaving, 'revenue' for purchases). Balances are
+-- credits minus debits, so the balances of all accounts in a currency sum to zero.
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id              uuid PRIMARY KEY,
+    user_id         uuid,                              -- NULL for system accounts
+    name            VARCHAR(50) NOT NULL,              -- 'wallet', 'external', 'revenue'
+    currency        VARCHAR(10) NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_wallet ON ledger_accounts (user_id, currency) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_system ON ledger_accounts (name, currency) WHERE user_id IS NULL;
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id              BIGSERIAL PRIMARY KEY,
+    transaction_id  uuid NOT NULL REFERENCES transactions (id),
+    account_id      uuid NOT NULL REFERENCES ledger_accounts (id),
+    direction       VARCHAR(6) NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount          NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+    currency        VARCHAR(10) NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries (account_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction ON ledger_entries (transaction_id);
+
+
+-- Exact amounts: four decimal places cover every ISO 4217 currency and fit the service's
+-- int64 fixed-point money type.
+ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(18, 4);
+ALTER TABLE ledger_entries ALTER COLUMN amount TYPE NUMERIC(18, 4);
+
+
+-- Status state machine: new statuses (processing, cancelled, reversed), a reason code per
+-- status change, and reversal entries in the ledger.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status_reason VARCHAR(50);
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS reversal BOOLEAN NOT NULL DEFAULT FALSE;
+
+
+-- Idempotency-Key support for POST /api/v1/transactions: one row per user and key, holding
+-- a fingerprint of the request and the response it produced.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id         uuid NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash    CHAR(64) NOT NULL,                 -- hex SHA-256 of the request
+    response        JSONB,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+
+-- Refunds and reversals: 'refund' and 'reversal' transactions point at the transaction they undo.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS parent_transaction_id uuid REFERENCES transactions (id);
+CREATE INDEX IF NOT EXISTS idx_transactions_parent ON transactions (parent_transaction_id) WHERE parent_transaction_id IS NOT NULL;
+
+
+-- Transfers between users: the 'transfer_out' and 'transfer_in' legs share a transfer_id.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS transfer_id uuid;
+CREATE INDEX IF NOT EXISTS idx_transactions_transfer ON transactions (transfer_id) WHERE transfer_id IS NOT NULL;
+
+
+-- Multi-currency: currencies are ISO 4217 codes validated by the service, so there is no
+-- default any more. Exchanges record the converted amount and the rate used.
+ALTER TABLE transactions ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE transactions ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS target_amount NUMERIC(18, 4);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS target_currency VARCHAR(10);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(19, 10);
+
+-- Exchange rates: one unit of base_currency buys `rate` of quote_currency from effective_at
+-- until a later rate of the same pair takes effect.
+CREATE TABLE IF NOT EXISTS fx_rates (
+    id              BIGSERIAL PRIMARY KEY,
+    base_currency   CHAR(3) NOT NULL,
+    quote_currency  CHAR(3) NOT NULL,
+    rate            NUMERIC(19, 10) NOT NULL CHECK (rate > 0),
+    effective_at    TIMESTAMP NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (base_currency, quote_currency, effective_at)
+);
+
+
+-- Append-only status history: one row per status a transaction takes, written in the same
+-- database transaction as the change. actor_id is NULL for changes made outside the service.
+CREATE TABLE IF NOT EXISTS transaction_status_history (
+    id              BIGSERIAL PRIMARY KEY,
+    transaction_id  uuid NOT NULL REFERENCES transactions (id),
+    previous_status VARCHAR(20),                       -- NULL for the initial status
+    status          VARCHAR(20) NOT NULL,
+    actor_id        uuid,
+    reason          VARCHAR(50),
+    request_id      VARCHAR(255),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_transaction_status_history_transaction ON transaction_status_history (transaction_id, id);
+
+CREATE OR REPLACE FUNCTION forbid_status_history_changes() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'transaction_status_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_transaction_status_history_append_only ON transaction_status_history;
+CREATE TRIGGER trg_transaction_status_history_append_only
+BEFORE UPDATE OR DELETE ON transaction_status_history
+FOR EACH ROW EXECUTE FUNCTION forbid_status_history_changes();
+
+
+-- Expiry of stale pending transactions: the expiry worker looks up pending transactions per
+-- type by age and moves them to 'expired'.
+CREATE INDEX IF NOT EXISTS idx_transactions_pending_expiry ON transactions (transaction_type, created_at) WHERE status = 'pending';
+
+
+-- Scheduled and recurring transactions: a transaction template run once at start_at, or at
+-- every occurrence of an RRULE (FREQ, INTERVAL, and BYDAY or BYMONTHDAY) until end_at. The
+-- scheduler creates each occurrence's transaction in the same database transaction that
+-- advances next_run_at.
+CREATE TABLE IF NOT EXISTS schedules (
+    id                  uuid PRIMARY KEY,
+    user_id             uuid NOT NULL,
+    amount              NUMERIC(18, 4) NOT NULL CHECK (amount > 0),
+    currency            VARCHAR(10) NOT NULL,
+    transaction_type    VARCHAR(50) NOT NULL,
+    description         TEXT,
+    recurrence          VARCHAR(100),                      -- NULL for a one-off schedule
+    start_at            TIMESTAMP NOT NULL,
+    end_at              TIMESTAMP,
+    status              VARCHAR(20) NOT NULL DEFAULT 'active', -- 'active', 'paused', 'cancelled', 'completed'
+    occurrence          INT NOT NULL DEFAULT 0,            -- occurrences before next_run_at
+    next_run_at         TIMESTAMP,                         -- NULL once no occurrence is left
+    last_transaction_id uuid REFERENCES transactions (id),
+    last_error          TEXT,
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_schedules_user ON schedules (user_id);
+CREATE INDEX IF NOT EXISTS idx_schedules_due ON schedules (next_run_at) WHERE status = 'active';
+
+-- An occurrence that failed for a reason other than the transaction rules is retried at retry_at.
+-- BYDAY and BYMONTHDAY lists can outgrow VARCHAR(100).
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS retry_at TIMESTAMP;
+ALTER TABLE schedules ALTER COLUMN recurrence TYPE TEXT;
+CREATE INDEX IF NOT EXISTS idx_schedules_due_retry ON schedules (COALESCE(retry_at, next_run_at)) WHERE status = 'active';
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"go-api-streaming/infrastructure/messaging"
+	"go-api-streaming/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeadLetterHandler struct {
+	useCase usecase.DeadLetterUseCase
+}
+
+func NewDeadLetterHandler(useCase usecase.DeadLetterUseCase) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		useCase: useCase,
+	}
+}
+
+// ListDeadLetters godoc
+// @Summary Peek at dead-lettered messages of a queue (admin)
+// @Tags dead-letters
+// @Produce json
+// @Param queue path string true "Queue name"
+// @Param limit query int false "Maximum messages" default(10)
+// @Success 200 {array} messaging.DeadLetter
+// @Router /admin/dead-letters/{queue} [get]
+func (h *DeadLetterHandler) ListDeadLetters(c *gin.Context) {
+	queueName := c.Param("queue")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	deadLetters, err := h.useCase.ListDeadLetters(queueName, limit)
+	if err != nil {
+		c.JSON(deadLetterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  deadLetters,
+		"queue": queueName,
+	})
+}
+
+// RedriveDeadLetters godoc
+// @Summary Move dead-lettered messages back to their queue (admin)
+// @Tags dead-letters
+// @Produce json
+// @Param queue path string true "Queue name"
+// @Param limit query int false "Maximum messages" default(10)
+// @Success 200 {object} object
+// @Router /admin/dead-letters/{queue}/redrive [post]
+func (h *DeadLetterHandler) RedriveDeadLetters(c *gin.Context) {
+	queueName := c.Param("queue")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	moved, err := h.useCase.RedriveDeadLetters(queueName, limit)
+	if err != nil {
+		c.JSON(deadLetterErrorStatus(err), gin.H{
+			"error":       err.Error(),
+			"redelivered": moved,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "dead letters redelivered successfully",
+		"queue":       queueName,
+		"redelivered": moved,
+	})
+}
+
+func deadLetterErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, messaging.ErrUnknownQueue):
+		return http.StatusNotFound
+	case errors.Is(err, messaging.ErrNotConnected):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"go-api-streaming/delivery/http/middleware"
+	"go-api-streaming/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateExchange godoc
+// @Summary Convert money between two of the user's currencies
+// @Tags exchanges
+// @Accept json
+// @Produce json
+// @Param exchange body usecase.CreateExchangeRequest true "Exchange data"
+// @Success 201 {object} entity.Transaction
+// @Router /exchanges [post]
+func (h *TransactionHandler) CreateExchange(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req usecase.CreateExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	transaction, err := h.useCase.CreateExchange(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "exchange completed successfully",
+		"data":    transaction,
+	})
+}
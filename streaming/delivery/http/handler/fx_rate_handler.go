@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"go-api-streaming/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FXRateHandler struct {
+	useCase usecase.FXUseCase
+}
+
+func NewFXRateHandler(useCase usecase.FXUseCase) *FXRateHandler {
+	return &FXRateHandler{
+		useCase: useCase,
+	}
+}
+
+// LoadFXRatesRequest is the body of LoadRates.
+type LoadFXRatesRequest struct {
+	Rates []*usecase.LoadFXRateRequest `json:"rates" binding:"required"`
+}
+
+// LoadRates godoc
+// @Summary Load exchange rates
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param rates body LoadFXRatesRequest true "Rates to load"
+// @Success 201 {array} entity.FXRate
+// @Router /admin/fx-rates [post]
+func (h *FXRateHandler) LoadRates(c *gin.Context) {
+	var req LoadFXRatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rates, err := h.useCase.LoadRates(c.Request.Context(), req.Rates)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidFXRate) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "exchange rates loaded successfully",
+		"data":    rates,
+	})
+}
+
+// ListRates godoc
+// @Summary List exchange rates, newest first
+// @Tags admin
+// @Produce json
+// @Param base query string false "Base currency"
+// @Param quote query string false "Quote currency"
+// @Param limit query int false "Maximum rates" default(100)
+// @Success 200 {array} entity.FXRate
+// @Router /admin/fx-rates [get]
+func (h *FXRateHandler) ListRates(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	rates, err := h.useCase.ListRates(c.Request.Context(), c.Query("base"), c.Query("quote"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rates})
+}
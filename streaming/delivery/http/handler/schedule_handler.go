@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"errors"
+	"go-api-streaming/delivery/http/middleware"
+	"go-api-streaming/domain/repository"
+	"go-api-streaming/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ScheduleHandler struct {
+	useCase usecase.ScheduleUseCase
+}
+
+func NewScheduleHandler(useCase usecase.ScheduleUseCase) *ScheduleHandler {
+	return &ScheduleHandler{
+		useCase: useCase,
+	}
+}
+
+// CreateSchedule godoc
+// @Summary Schedule a future or recurring transaction
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param schedule body usecase.CreateScheduleRequest true "Schedule"
+// @Success 201 {object} entity.Schedule
+// @Router /schedules [post]
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req usecase.CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.useCase.CreateSchedule(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "schedule created successfully",
+		"data":    schedule,
+	})
+}
+
+// ListSchedules godoc
+// @Summary List the user's schedules
+// @Tags schedules
+// @Produce json
+// @Success 200 {array} entity.Schedule
+// @Router /schedules [get]
+func (h *ScheduleHandler) ListSchedules(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	schedules, err := h.useCase.ListSchedules(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedules})
+}
+
+// GetSchedule godoc
+// @Summary Get a schedule
+// @Tags schedules
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} entity.Schedule
+// @Router /schedules/{id} [get]
+func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
+	userID, id, ok := scheduleParams(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.useCase.GetSchedule(c.Request.Context(), id, userID)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedule})
+}
+
+// UpdateSchedule godoc
+// @Summary Update a schedule's future occurrences
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Param schedule body usecase.UpdateScheduleRequest true "Fields to change"
+// @Success 200 {object} entity.Schedule
+// @Router /schedules/{id} [patch]
+func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
+	userID, id, ok := scheduleParams(c)
+	if !ok {
+		return
+	}
+
+	var req usecase.UpdateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.useCase.UpdateSchedule(c.Request.Context(), id, userID, &req)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "schedule updated successfully",
+		"data":    schedule,
+	})
+}
+
+// PauseSchedule godoc
+// @Summary Pause a schedule
+// @Tags schedules
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} entity.Schedule
+// @Router /schedules/{id}/pause [post]
+func (h *ScheduleHandler) PauseSchedule(c *gin.Context) {
+	userID, id, ok := scheduleParams(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.useCase.PauseSchedule(c.Request.Context(), id, userID)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "schedule paused",
+		"data":    schedule,
+	})
+}
+
+// ResumeSchedule godoc
+// @Summary Resume a paused schedule
+// @Tags schedules
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} entity.Schedule
+// @Router /schedules/{id}/resume [post]
+func (h *ScheduleHandler) ResumeSchedule(c *gin.Context) {
+	userID, id, ok := scheduleParams(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.useCase.ResumeSchedule(c.Request.Context(), id, userID)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "schedule resumed",
+		"data":    schedule,
+	})
+}
+
+// CancelSchedule godoc
+// @Summary Cancel a schedule
+// @Tags schedules
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} entity.Schedule
+// @Router /schedules/{id} [delete]
+func (h *ScheduleHandler) CancelSchedule(c *gin.Context) {
+	userID, id, ok := scheduleParams(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.useCase.CancelSchedule(c.Request.Context(), id, userID)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "schedule cancelled",
+		"data":    schedule,
+	})
+}
+
+// scheduleParams reads the caller and the schedule ID, writing the error
+// response itself when either is missing.
+func scheduleParams(c *gin.Context) (userID, id uuid.UUID, ok bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, id, true
+}
+
+func scheduleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrScheduleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidSchedule):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrScheduleStatus):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-api-streaming/delivery/http/middleware"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/domain/repository"
+	"go-api-streaming/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type StreamHandler struct {
+	useCase           usecase.StreamUseCase
+	heartbeatInterval time.Duration
+}
+
+func NewStreamHandler(useCase usecase.StreamUseCase, heartbeatInterval time.Duration) *StreamHandler {
+	return &StreamHandler{
+		useCase:           useCase,
+		heartbeatInterval: heartbeatInterval,
+	}
+}
+
+// StreamTransactions godoc
+// @Summary Stream the user's transaction events over Server-Sent Events
+// @Tags transactions
+// @Produce text/event-stream
+// @Param status query string false "Comma-separated transaction statuses"
+// @Param type query string false "Comma-separated transaction types"
+// @Param event query string false "Comma-separated event types"
+// @Param since query int false "Resume after this event sequence (same as Last-Event-ID)"
+// @Success 200 {string} string "event stream"
+// @Router /transactions/stream [get]
+func (h *StreamHandler) StreamTransactions(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	since, resume, err := streamCursor(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := usecase.StreamFilter{
+		UserID:           userID,
+		Statuses:         splitQuery(c.Query("status")),
+		TransactionTypes: splitQuery(c.Query("type")),
+		EventTypes:       splitQuery(c.Query("event")),
+	}
+
+	// Subscribe before replaying so nothing published during the replay is
+	// lost; duplicates are skipped by sequence below.
+	sub := h.useCase.Subscribe(filter)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	var replayed int64
+	if resume {
+		replayed, err = h.useCase.Replay(c.Request.Context(), filter, since, func(envelope *events.Envelope) error {
+			writeEnvelope(c, envelope)
+			return c.Request.Context().Err()
+		})
+		if err != nil {
+			writeSSE(c, "", "error", gin.H{"error": "replay failed, please reconnect"})
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case envelope, ok := <-sub.C:
+			if !ok {
+				// Dropped by the hub; the client reconnects and catches up.
+				writeSSE(c, "", "error", gin.H{"error": "stream closed, please reconnect"})
+				return
+			}
+			if envelope.Sequence != 0 && envelope.Sequence <= replayed {
+				continue
+			}
+			writeEnvelope(c, envelope)
+		case now := <-heartbeat.C:
+			writeSSE(c, "", "heartbeat", gin.H{"time": now.UTC()})
+		}
+	}
+}
+
+// WaitForTransaction godoc
+// @Summary Wait until a transaction leaves a status
+// @Tags transactions
+// @Produce json
+// @Param id path string true "Transaction ID"
+// @Param from query string false "Status to wait to leave" default(pending)
+// @Param timeout query string false "Maximum wait, e.g. 30s" default(30s)
+// @Success 200 {object} entity.Transaction
+// @Router /transactions/{id}/wait [get]
+func (h *StreamHandler) WaitForTransaction(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction ID"})
+		return
+	}
+
+	timeout, err := time.ParseDuration(c.DefaultQuery("timeout", "30s"))
+	if err != nil || timeout <= 0 || timeout > maxWaitTimeout {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("timeout must be a duration between 0s and %s", maxWaitTimeout)})
+		return
+	}
+
+	from := c.DefaultQuery("from", entity.TransactionStatusPending)
+
+	transaction, changed, err := h.useCase.WaitForStatusChange(c.Request.Context(), id, userID, middleware.IsAdmin(c), from, timeout)
+	if err != nil {
+		if errors.Is(err, repository.ErrTransactionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    transaction,
+		"changed": changed,
+	})
+}
+
+// maxWaitTimeout bounds how long a wait request may hold its connection.
+const maxWaitTimeout = 60 * time.Second
+
+// streamCursor reads the resume position from the Last-Event-ID header or the
+// since query parameter. resume is false when neither is present.
+func streamCursor(c *gin.Context) (since int64, resume bool, err error) {
+	cursor := c.GetHeader("Last-Event-ID")
+	if cursor == "" {
+		cursor = c.Query("since")
+	}
+	if cursor == "" {
+		return 0, false, nil
+	}
+
+	since, err = strconv.ParseInt(cursor, 10, 64)
+	if err != nil || since < 0 {
+		return 0, false, fmt.Errorf("invalid event cursor: %s", cursor)
+	}
+	return since, true, nil
+}
+
+// writeEnvelope writes an event with its log sequence as the SSE id, which
+// the client sends back as Last-Event-ID when it reconnects.
+func writeEnvelope(c *gin.Context, envelope *events.Envelope) {
+	id := ""
+	if envelope.Sequence != 0 {
+		id = strconv.FormatInt(envelope.Sequence, 10)
+	}
+	writeSSE(c, id, envelope.Type, envelope)
+}
+
+// writeSSE writes one Server-Sent Event and flushes it to the client.
+func writeSSE(c *gin.Context, id, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	if id != "" {
+		fmt.Fprintf(c.Writer, "id: %s\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, payload)
+	c.Writer.Flush()
+}
+
+// splitQuery splits a comma-separated query value, dropping empty entries.
+func splitQuery(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
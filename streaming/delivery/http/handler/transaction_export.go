@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"go-api-streaming/delivery/http/middleware"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/repository"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// exportFlushEvery is the number of rows written between flushes, so the
+// client receives the export in chunks while it is being read.
+const exportFlushEvery = 100
+
+// ExportTransactions godoc
+// @Summary Export transactions as NDJSON or CSV
+// @Tags transactions
+// @Produce application/x-ndjson,text/csv
+// @Param format query string false "ndjson or csv" default(ndjson)
+// @Param status query string false "Transaction status"
+// @Param user_id query string false "User ID (admin only, defaults to the caller for other users)"
+// @Success 200 {string} string "export"
+// @Router /transactions/export [get]
+func (h *TransactionHandler) ExportTransactions(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "ndjson")
+	var writer exportWriter
+	switch format {
+	case "ndjson":
+		writer = &ndjsonExportWriter{c: c}
+	case "csv":
+		writer = &csvExportWriter{c: c, w: csv.NewWriter(c.Writer)}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be ndjson or csv"})
+		return
+	}
+
+	filter, ok := scopedTransactionFilter(c, userID)
+	if !ok {
+		return
+	}
+
+	// The status code and headers are only sent with the first row, so errors
+	// raised before any output still get a proper JSON response.
+	started := false
+	rows := 0
+	start := func() {
+		started = true
+		c.Header("Content-Disposition", "attachment; filename=transactions."+format)
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		writer.start()
+	}
+
+	err = h.useCase.ExportTransactions(c.Request.Context(), filter, func(transaction *entity.Transaction) error {
+		if !started {
+			start()
+		}
+		if err := writer.write(transaction); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushEvery == 0 {
+			// Stop reading rows once the client is gone.
+			return writer.flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		// Too late to report it to the client; the export is cut short.
+		log.Printf("Transaction export aborted after %d rows: %v", rows, err)
+		return
+	}
+
+	if !started {
+		start()
+	}
+	if err := writer.flush(); err != nil {
+		log.Printf("Transaction export failed after %d rows: %v", rows, err)
+	}
+}
+
+// scopedTransactionFilter builds a filter from the status and user_id query
+// parameters. Admins see every user's transactions unless they pick one;
+// everyone else only sees their own. It writes the error response itself when
+// the parameters are invalid.
+func scopedTransactionFilter(c *gin.Context, userID uuid.UUID) (repository.TransactionFilter, bool) {
+	filter := repository.TransactionFilter{
+		UserID: userID,
+		Status: c.Query("status"),
+	}
+	if middleware.IsAdmin(c) {
+		filter.UserID = uuid.Nil
+		if raw := c.Query("user_id"); raw != "" {
+			var err error
+			filter.UserID, err = uuid.Parse(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+				return filter, false
+			}
+		}
+	}
+	return filter, true
+}
+
+// exportWriter encodes transactions onto the response in one format.
+type exportWriter interface {
+	start()
+	write(transaction *entity.Transaction) error
+	// flush sends the buffered rows and returns any error writing them.
+	flush() error
+}
+
+type ndjsonExportWriter struct {
+	c *gin.Context
+}
+
+func (w *ndjsonExportWriter) start() {
+	w.c.Header("Content-Type", "application/x-ndjson")
+	w.c.Status(http.StatusOK)
+}
+
+func (w *ndjsonExportWriter) write(transaction *entity.Transaction) error {
+	line, err := json.Marshal(transaction)
+	if err != nil {
+		return err
+	}
+	if _, err := w.c.Writer.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w *ndjsonExportWriter) flush() error {
+	// Rows are written unbuffered, so write already reported any error.
+	w.c.Writer.Flush()
+	return nil
+}
+
+type csvExportWriter struct {
+	c *gin.Context
+	w *csv.Writer
+}
+
+var csvExportHeader = []string{
+	"id", "user_id", "amount", "currency", "transaction_type", "status", "description", "created_at", "updated_at",
+}
+
+func (w *csvExportWriter) start() {
+	w.c.Header("Content-Type", "text/csv; charset=utf-8")
+	w.c.Status(http.StatusOK)
+	w.w.Write(csvExportHeader)
+}
+
+func (w *csvExportWriter) write(transaction *entity.Transaction) error {
+	description := ""
+	if transaction.Description != nil {
+		description = *transaction.Description
+	}
+
+	row := []string{
+		transaction.ID.String(),
+		transaction.UserID.String(),
+		transaction.Amount.String(),
+		transaction.Currency,
+		transaction.TransactionType,
+		transaction.Status,
+		description,
+		transaction.CreatedAt.UTC().Format(time.RFC3339Nano),
+		transaction.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	for i, cell := range row {
+		row[i] = escapeCSVFormula(cell)
+	}
+
+	return w.w.Write(row)
+}
+
+// escapeCSVFormula prefixes a cell that spreadsheets would read as a formula
+// with a quote, so a description such as "=HYPERLINK(...)" is shown as text
+// when the export is opened.
+func escapeCSVFormula(cell string) string {
+	if cell == "" {
+		return cell
+	}
+	switch cell[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + cell
+	}
+	return cell
+}
+
+// flush returns the csv.Writer's error, as it buffers rows and only reports
+// failures to write them there.
+func (w *csvExportWriter) flush() error {
+	w.w.Flush()
+	w.c.Writer.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/csv"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/money"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestEscapeCSVFormula(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{cell: "", want: ""},
+		{cell: "coffee", want: "coffee"},
+		{cell: "12.5", want: "12.5"},
+		{cell: "a=b", want: "a=b"},
+		{cell: "=1+1", want: "'=1+1"},
+		{cell: `=HYPERLINK("http://example.com","x")`, want: `'=HYPERLINK("http://example.com","x")`},
+		{cell: "+31 20 555", want: "'+31 20 555"},
+		{cell: "-2+3", want: "'-2+3"},
+		{cell: "@SUM(A1:A2)", want: "'@SUM(A1:A2)"},
+		{cell: "\t=1", want: "'\t=1"},
+		{cell: "\r=1", want: "'\r=1"},
+		{cell: " =1", want: " =1"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeCSVFormula(tt.cell); got != tt.want {
+			t.Errorf("escapeCSVFormula(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestCSVExportWriter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	writer := &csvExportWriter{c: c, w: csv.NewWriter(c.Writer)}
+
+	description := `=cmd|' /C calc'!A0`
+	createdAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	transaction := &entity.Transaction{
+		ID:              uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		UserID:          uuid.MustParse("00000000-0000-0000-0000-000000000002"),
+		Amount:          money.MustParse("12.3456"),
+		Currency:        "USD",
+		TransactionType: entity.TransactionTypePurchase,
+		Status:          entity.TransactionStatusSuccess,
+		Description:     &description,
+		CreatedAt:       createdAt,
+		UpdatedAt:       createdAt,
+	}
+
+	writer.start()
+	if err := writer.write(transaction); err != nil {
+		t.Fatalf("write error = %v", err)
+	}
+	if err := writer.flush(); err != nil {
+		t.Fatalf("flush error = %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(recorder.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the export: %v", err)
+	}
+	want := [][]string{
+		csvExportHeader,
+		{
+			"00000000-0000-0000-0000-000000000001",
+			"00000000-0000-0000-0000-000000000002",
+			"12.3456",
+			"USD",
+			"purchase",
+			"success",
+			`'=cmd|' /C calc'!A0`,
+			"2026-03-01T09:30:00Z",
+			"2026-03-01T09:30:00Z",
+		},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("export has %d records, want %d: %q", len(records), len(want), records)
+	}
+	for i := range want {
+		if strings.Join(records[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("record %d = %q, want %q", i, records[i], want[i])
+		}
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/csv; charset=utf-8", got)
+	}
+}
@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"go-api-streaming/delivery/http/middleware"
+	"go-api-streaming/domain/money"
+	"go-api-streaming/domain/repository"
 	"go-api-streaming/usecase"
 	"net/http"
 	"strconv"
@@ -20,11 +23,15 @@ func NewTransactionHandler(useCase usecase.TransactionUseCase) *TransactionHandl
 	}
 }
 
+// maxIdempotencyKeyLength is the longest Idempotency-Key header accepted.
+const maxIdempotencyKeyLength = 255
+
 // CreateTransaction godoc
 // @Summary Create a new transaction
 // @Tags transactions
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
 // @Param transaction body usecase.CreateTransactionRequest true "Transaction data"
 // @Success 201 {object} entity.Transaction
 // @Router /transactions [post]
@@ -57,9 +64,15 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 
 	req.UserID = userID
 
+	req.IdempotencyKey = c.GetHeader("Idempotency-Key")
+	if len(req.IdempotencyKey) > maxIdempotencyKeyLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+		return
+	}
+
 	transaction, err := h.useCase.CreateTransaction(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -84,9 +97,15 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 		return
 	}
 
-	transaction, err := h.useCase.GetTransaction(c.Request.Context(), id)
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	transaction, err := h.useCase.GetTransaction(c.Request.Context(), id, userID, middleware.IsAdmin(c))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -143,6 +162,7 @@ func (h *TransactionHandler) UpdateTransactionStatus(c *gin.Context) {
 
 	var req struct {
 		Status string `json:"status" binding:"required"`
+		Reason string `json:"reason"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -150,9 +170,16 @@ func (h *TransactionHandler) UpdateTransactionStatus(c *gin.Context) {
 		return
 	}
 
-	transaction, err := h.useCase.UpdateTransactionStatus(c.Request.Context(), id, req.Status)
+	// The acting user is kept in the transaction's status history
+	actorID, err := middleware.GetUserID(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	transaction, err := h.useCase.UpdateTransactionStatus(c.Request.Context(), id, actorID, middleware.IsAdmin(c), req.Status, req.Reason)
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -208,7 +235,7 @@ func (h *TransactionHandler) GetTransactionsByStatus(c *gin.Context) {
 
 	transactions, err := h.useCase.GetTransactionsByStatus(c.Request.Context(), status, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -219,3 +246,29 @@ func (h *TransactionHandler) GetTransactionsByStatus(c *gin.Context) {
 		"page_size": pageSize,
 	})
 }
+
+func transactionErrorStatus(err error) int {
+	var transitionErr *usecase.TransitionError
+	switch {
+	case errors.As(err, &transitionErr):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrInvalidStatusChange),
+		errors.Is(err, usecase.ErrInvalidFilter):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrTransactionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrStatusChangeForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, usecase.ErrInsufficientFunds),
+		errors.Is(err, money.ErrAmountOutOfRange),
+		errors.Is(err, usecase.ErrInvalidTransaction),
+		errors.Is(err, usecase.ErrIdempotencyKeyReused),
+		errors.Is(err, usecase.ErrInvalidRefund),
+		errors.Is(err, usecase.ErrInvalidTransfer),
+		errors.Is(err, usecase.ErrInvalidExchange),
+		errors.Is(err, usecase.ErrNoExchangeRate):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"go-api-streaming/domain/repository"
+	"go-api-streaming/usecase"
+	"net/http"
+	"testing"
+)
+
+func TestTransactionErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: usecase.ErrIdempotencyKeyReused, want: http.StatusUnprocessableEntity},
+		{err: fmt.Errorf("claim: %w", usecase.ErrIdempotencyKeyReused), want: http.StatusUnprocessableEntity},
+		{err: repository.ErrTransactionNotFound, want: http.StatusNotFound},
+		{err: fmt.Errorf("%w: only 40 USD of the parent transaction is left to refund", usecase.ErrInvalidRefund), want: http.StatusUnprocessableEntity},
+		{err: errors.New("connection refused"), want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := transactionErrorStatus(tt.err); got != tt.want {
+			t.Errorf("transactionErrorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"go-api-streaming/delivery/http/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetTransactionHistory godoc
+// @Summary Get the status history of a transaction
+// @Tags transactions
+// @Produce json
+// @Param id path string true "Transaction ID"
+// @Success 200 {array} entity.TransactionStatusChange
+// @Router /transactions/{id}/history [get]
+func (h *TransactionHandler) GetTransactionHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction ID"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	history, err := h.useCase.GetTransactionHistory(c.Request.Context(), id, userID, middleware.IsAdmin(c))
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": history,
+	})
+}
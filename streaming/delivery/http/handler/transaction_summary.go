@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"go-api-streaming/delivery/http/middleware"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SummarizeTransactions godoc
+// @Summary Total transactions per type and currency, converted to a base currency
+// @Tags transactions
+// @Produce json
+// @Param base query string true "ISO 4217 base currency"
+// @Param at query string false "RFC 3339 time whose rates are used (defaults to now)"
+// @Param status query string false "Transaction status"
+// @Param user_id query string false "User ID (admin only, defaults to the caller for other users)"
+// @Success 200 {object} entity.TransactionSummary
+// @Router /transactions/summary [get]
+func (h *TransactionHandler) SummarizeTransactions(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	base := c.Query("base")
+	if base == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "base currency is required"})
+		return
+	}
+
+	at := time.Now()
+	if raw := c.Query("at"); raw != "" {
+		at, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC 3339 time"})
+			return
+		}
+	}
+
+	filter, ok := scopedTransactionFilter(c, userID)
+	if !ok {
+		return
+	}
+
+	summary, err := h.useCase.SummarizeTransactions(c.Request.Context(), filter, base, at)
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
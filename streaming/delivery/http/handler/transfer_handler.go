@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"go-api-streaming/delivery/http/middleware"
+	"go-api-streaming/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateTransfer godoc
+// @Summary Transfer money to another user
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param transfer body usecase.CreateTransferRequest true "Transfer data"
+// @Success 201 {object} entity.Transfer
+// @Router /transfers [post]
+func (h *TransactionHandler) CreateTransfer(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req usecase.CreateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.SenderID = userID
+
+	transfer, err := h.useCase.CreateTransfer(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "transfer completed successfully",
+		"data":    transfer,
+	})
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"go-api-streaming/delivery/http/middleware"
+	"go-api-streaming/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WalletHandler struct {
+	useCase usecase.WalletUseCase
+}
+
+func NewWalletHandler(useCase usecase.WalletUseCase) *WalletHandler {
+	return &WalletHandler{
+		useCase: useCase,
+	}
+}
+
+// GetMyWallets godoc
+// @Summary Get the user's balance in each currency
+// @Tags wallets
+// @Produce json
+// @Success 200 {array} entity.Wallet
+// @Router /wallets/me [get]
+func (h *WalletHandler) GetMyWallets(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	wallets, err := h.useCase.GetWallets(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": wallets})
+}
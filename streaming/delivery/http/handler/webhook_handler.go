@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"errors"
+	"go-api-streaming/delivery/http/middleware"
+	"go-api-streaming/domain/repository"
+	"go-api-streaming/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	useCase usecase.WebhookUseCase
+}
+
+func NewWebhookHandler(useCase usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{
+		useCase: useCase,
+	}
+}
+
+// CreateWebhook godoc
+// @Summary Subscribe a URL to the user's transaction events
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body usecase.CreateWebhookRequest true "Webhook subscription"
+// @Success 201 {object} entity.WebhookSubscription
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req usecase.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.useCase.CreateSubscription(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// The secret is only ever returned here.
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "webhook created successfully",
+		"data":    subscription,
+		"secret":  subscription.Secret,
+	})
+}
+
+// ListWebhooks godoc
+// @Summary List the user's webhook subscriptions
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} entity.WebhookSubscription
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	subscriptions, err := h.useCase.ListSubscriptions(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subscriptions})
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook subscription
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} entity.WebhookSubscription
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	userID, id, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.useCase.GetSubscription(c.Request.Context(), id, userID)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subscription})
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook subscription
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param webhook body usecase.UpdateWebhookRequest true "Fields to change"
+// @Success 200 {object} entity.WebhookSubscription
+// @Router /webhooks/{id} [patch]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	userID, id, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	var req usecase.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.useCase.UpdateSubscription(c.Request.Context(), id, userID, &req)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook updated successfully",
+		"data":    subscription,
+	})
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook subscription
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} object
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, id, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.useCase.DeleteSubscription(c.Request.Context(), id, userID); err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "webhook deleted successfully"})
+}
+
+// ListWebhookDeliveries godoc
+// @Summary List recent deliveries of a webhook subscription
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param limit query int false "Maximum deliveries" default(10)
+// @Success 200 {array} entity.WebhookDelivery
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	userID, id, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	deliveries, err := h.useCase.ListDeliveries(c.Request.Context(), id, userID, limit)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": deliveries})
+}
+
+// ListWebhookDeliveryAttempts godoc
+// @Summary List the HTTP attempts of a webhook delivery
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 200 {array} entity.WebhookDeliveryAttempt
+// @Router /webhooks/{id}/deliveries/{deliveryId}/attempts [get]
+func (h *WebhookHandler) ListWebhookDeliveryAttempts(c *gin.Context) {
+	userID, id, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery ID"})
+		return
+	}
+
+	attempts, err := h.useCase.ListAttempts(c.Request.Context(), id, deliveryID, userID)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": attempts})
+}
+
+// RedeliverWebhook godoc
+// @Summary Send a webhook delivery again
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 202 {object} entity.WebhookDelivery
+// @Router /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (h *WebhookHandler) RedeliverWebhook(c *gin.Context) {
+	userID, id, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery ID"})
+		return
+	}
+
+	delivery, err := h.useCase.Redeliver(c.Request.Context(), id, deliveryID, userID)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "webhook delivery queued",
+		"data":    delivery,
+	})
+}
+
+// webhookParams reads the caller and the webhook ID, writing the error
+// response itself when either is missing.
+func webhookParams(c *gin.Context) (userID, id uuid.UUID, ok bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, id, true
+}
+
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrWebhookNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidWebhook):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
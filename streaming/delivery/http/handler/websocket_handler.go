@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"go-api-streaming/delivery/http/middleware"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/infrastructure/stream"
+	"go-api-streaming/usecase"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait      = 10 * time.Second
+	wsMaxMessageSize = 4096
+)
+
+// wsClientMessage is a message sent by a WebSocket client.
+type wsClientMessage struct {
+	Action string `json:"action"` // "subscribe", "unsubscribe" or "ping"
+	Topic  string `json:"topic,omitempty"`
+}
+
+// wsServerMessage is a message sent to a WebSocket client.
+type wsServerMessage struct {
+	Type   string           `json:"type"` // "subscribed", "unsubscribed", "event", "pong" or "error"
+	Topic  string           `json:"topic,omitempty"`
+	Topics []string         `json:"topics,omitempty"`
+	Event  *events.Envelope `json:"event,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+type WebSocketHandler struct {
+	useCase      usecase.StreamUseCase
+	upgrader     websocket.Upgrader
+	pingInterval time.Duration
+}
+
+func NewWebSocketHandler(useCase usecase.StreamUseCase, pingInterval time.Duration) *WebSocketHandler {
+	return &WebSocketHandler{
+		useCase: useCase,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// Same policy as the CORS middleware: any origin may connect,
+			// the JWT is what authorises the connection.
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		pingInterval: pingInterval,
+	}
+}
+
+// wsTopics is the set of topics a connection is subscribed to.
+type wsTopics struct {
+	mu      sync.RWMutex
+	filters map[string]usecase.StreamFilter
+}
+
+// match returns the subscribed topics that envelope matches.
+func (t *wsTopics) match(envelope *events.Envelope) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var topics []string
+	for topic, filter := range t.filters {
+		if filter.Match(envelope) {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// Connect godoc
+// @Summary Open a WebSocket for live transaction events with dynamic subscriptions
+// @Tags transactions
+// @Param token query string false "JWT, for clients that cannot set the Authorization header"
+// @Success 101 {string} string "switching protocols"
+// @Router /ws [get]
+func (h *WebSocketHandler) Connect(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	isAdmin := middleware.IsAdmin(c)
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written an error response.
+		return
+	}
+	defer conn.Close()
+
+	topics := &wsTopics{filters: make(map[string]usecase.StreamFilter)}
+	sub := h.useCase.SubscribeFunc(func(envelope *events.Envelope) bool {
+		return len(topics.match(envelope)) > 0
+	})
+	defer sub.Close()
+
+	// Replies from the read loop go through the writer, which owns the
+	// connection's write side.
+	replies := make(chan wsServerMessage, 16)
+	done := make(chan struct{})
+	go h.writeLoop(conn, sub, topics, replies, done)
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(2 * h.pingInterval))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.pingInterval))
+	})
+
+	for {
+		var msg wsClientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			break
+		}
+
+		reply := h.handleClientMessage(msg, topics, userID, isAdmin)
+		select {
+		case replies <- reply:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (h *WebSocketHandler) handleClientMessage(msg wsClientMessage, topics *wsTopics, userID uuid.UUID, isAdmin bool) wsServerMessage {
+	switch msg.Action {
+	case "subscribe":
+		filter, err := usecase.TopicFilter(msg.Topic, userID, isAdmin)
+		if err != nil {
+			return wsServerMessage{Type: "error", Topic: msg.Topic, Error: err.Error()}
+		}
+		topics.mu.Lock()
+		topics.filters[msg.Topic] = filter
+		topics.mu.Unlock()
+		return wsServerMessage{Type: "subscribed", Topic: msg.Topic}
+
+	case "unsubscribe":
+		topics.mu.Lock()
+		delete(topics.filters, msg.Topic)
+		topics.mu.Unlock()
+		return wsServerMessage{Type: "unsubscribed", Topic: msg.Topic}
+
+	case "ping":
+		return wsServerMessage{Type: "pong"}
+
+	default:
+		return wsServerMessage{Type: "error", Error: "unknown action: " + msg.Action}
+	}
+}
+
+// writeLoop sends events, replies and pings until the subscription or the
+// connection ends. A subscription dropped by the hub for being too slow
+// closes the connection so the hub never waits on this client.
+func (h *WebSocketHandler) writeLoop(
+	conn *websocket.Conn,
+	sub *stream.Subscription,
+	topics *wsTopics,
+	replies <-chan wsServerMessage,
+	done chan<- struct{},
+) {
+	defer close(done)
+	defer conn.Close()
+
+	ping := time.NewTicker(h.pingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case envelope, ok := <-sub.C:
+			if !ok {
+				if sub.Err() != nil {
+					conn.WriteControl(
+						websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "client too slow, please reconnect"),
+						time.Now().Add(wsWriteWait),
+					)
+				}
+				return
+			}
+			matched := topics.match(envelope)
+			if len(matched) == 0 {
+				continue // unsubscribed after the hub matched it
+			}
+			err = h.write(conn, wsServerMessage{Type: "event", Topics: matched, Event: envelope})
+
+		case reply := <-replies:
+			err = h.write(conn, reply)
+
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			err = conn.WriteMessage(websocket.PingMessage, nil)
+		}
+
+		if err != nil {
+			log.Printf("WebSocket write failed: %v", err)
+			return
+		}
+	}
+}
+
+func (h *WebSocketHandler) write(conn *websocket.Conn, msg wsServerMessage) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return conn.WriteJSON(msg)
+}
@@ -11,19 +11,27 @@ import (
 )
 
 type AuthMiddleware struct {
-	jwtSecret string
+	jwtSecret   string
+	adminEmails map[string]bool
 }
 
 type Claims struct {
 	UserID uuid.UUID `json:"user_id"` // For backward compatibility
 	Sub    string    `json:"sub"`     // Standard JWT claim (used by auth service)
 	Email  string    `json:"email"`
+	Role   string    `json:"role"`
 	jwt.RegisteredClaims
 }
 
-func NewAuthMiddleware(jwtSecret string) *AuthMiddleware {
+func NewAuthMiddleware(jwtSecret string, adminEmails []string) *AuthMiddleware {
+	admins := make(map[string]bool, len(adminEmails))
+	for _, email := range adminEmails {
+		admins[strings.ToLower(email)] = true
+	}
+
 	return &AuthMiddleware{
-		jwtSecret: jwtSecret,
+		jwtSecret:   jwtSecret,
+		adminEmails: admins,
 	}
 }
 
@@ -104,6 +112,7 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 			// Set user info in context
 			c.Set("user_id", userID)
 			c.Set("email", claims.Email)
+			c.Set("is_admin", claims.Role == "admin" || m.adminEmails[strings.ToLower(claims.Email)])
 			c.Next()
 		} else {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
@@ -113,6 +122,37 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 	}
 }
 
+// AuthenticateWebSocket behaves like Authenticate but also accepts the token in
+// the "token" query parameter, because browsers cannot set headers on a
+// WebSocket handshake.
+func (m *AuthMiddleware) AuthenticateWebSocket() gin.HandlerFunc {
+	authenticate := m.Authenticate()
+	return func(c *gin.Context) {
+		if token := c.Query("token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		authenticate(c)
+	}
+}
+
+// RequireAdmin must run after Authenticate. It rejects callers that neither
+// carry the "admin" role claim nor have an email listed in ADMIN_EMAILS.
+func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Helper function to check whether the authenticated user is an admin
+func IsAdmin(c *gin.Context) bool {
+	return c.GetBool("is_admin")
+}
+
 // Helper function to get user ID from context
 func GetUserID(c *gin.Context) (uuid.UUID, error) {
 	userID, exists := c.Get("user_id")
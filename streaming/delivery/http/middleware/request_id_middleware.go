@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"go-api-streaming/domain/events"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID reuses the caller's X-Request-ID or generates one, echoes it in
+// the response and stores it in the request context as the correlation ID of
+// any event the request produces.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.New().String()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(events.WithCorrelationID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
+// Helper function to get the request ID from context
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}
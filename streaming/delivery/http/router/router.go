@@ -9,15 +9,23 @@ import (
 
 func SetupRouter(
 	transactionHandler *handler.TransactionHandler,
+	streamHandler *handler.StreamHandler,
+	webSocketHandler *handler.WebSocketHandler,
+	webhookHandler *handler.WebhookHandler,
+	walletHandler *handler.WalletHandler,
+	fxRateHandler *handler.FXRateHandler,
+	scheduleHandler *handler.ScheduleHandler,
+	deadLetterHandler *handler.DeadLetterHandler,
 	authMiddleware *middleware.AuthMiddleware,
 ) *gin.Engine {
 	router := gin.Default()
+	router.Use(middleware.RequestID())
 
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -46,10 +54,76 @@ func SetupRouter(
 			transactions.POST("", transactionHandler.CreateTransaction)
 			transactions.GET("/:id", transactionHandler.GetTransaction)
 			transactions.GET("/my", transactionHandler.GetUserTransactions)
+			transactions.GET("/stream", streamHandler.StreamTransactions)
+			transactions.GET("/export", transactionHandler.ExportTransactions)
+			transactions.GET("/summary", transactionHandler.SummarizeTransactions)
+			transactions.GET("/:id/wait", streamHandler.WaitForTransaction)
+			transactions.GET("/:id/history", transactionHandler.GetTransactionHistory)
 			transactions.PATCH("/:id/status", transactionHandler.UpdateTransactionStatus)
 			transactions.GET("", transactionHandler.GetAllTransactions)
 			transactions.GET("/status", transactionHandler.GetTransactionsByStatus)
 		}
+
+		// Transfer routes (protected)
+		transfers := api.Group("/transfers")
+		transfers.Use(authMiddleware.Authenticate())
+		{
+			transfers.POST("", transactionHandler.CreateTransfer)
+		}
+
+		// Exchange routes (protected)
+		exchanges := api.Group("/exchanges")
+		exchanges.Use(authMiddleware.Authenticate())
+		{
+			exchanges.POST("", transactionHandler.CreateExchange)
+		}
+
+		// Wallet routes (protected)
+		wallets := api.Group("/wallets")
+		wallets.Use(authMiddleware.Authenticate())
+		{
+			wallets.GET("/me", walletHandler.GetMyWallets)
+		}
+
+		// Webhook subscriptions (protected, scoped to the caller)
+		webhooks := api.Group("/webhooks")
+		webhooks.Use(authMiddleware.Authenticate())
+		{
+			webhooks.POST("", webhookHandler.CreateWebhook)
+			webhooks.GET("", webhookHandler.ListWebhooks)
+			webhooks.GET("/:id", webhookHandler.GetWebhook)
+			webhooks.PATCH("/:id", webhookHandler.UpdateWebhook)
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", webhookHandler.ListWebhookDeliveries)
+			webhooks.GET("/:id/deliveries/:deliveryId/attempts", webhookHandler.ListWebhookDeliveryAttempts)
+			webhooks.POST("/:id/deliveries/:deliveryId/redeliver", webhookHandler.RedeliverWebhook)
+		}
+
+		// Schedules (protected, scoped to the caller)
+		schedules := api.Group("/schedules")
+		schedules.Use(authMiddleware.Authenticate())
+		{
+			schedules.POST("", scheduleHandler.CreateSchedule)
+			schedules.GET("", scheduleHandler.ListSchedules)
+			schedules.GET("/:id", scheduleHandler.GetSchedule)
+			schedules.PATCH("/:id", scheduleHandler.UpdateSchedule)
+			schedules.DELETE("/:id", scheduleHandler.CancelSchedule)
+			schedules.POST("/:id/pause", scheduleHandler.PauseSchedule)
+			schedules.POST("/:id/resume", scheduleHandler.ResumeSchedule)
+		}
+
+		// WebSocket (protected, token may also be passed as ?token=)
+		api.GET("/ws", authMiddleware.AuthenticateWebSocket(), webSocketHandler.Connect)
+
+		// Admin routes (protected, admin only)
+		admin := api.Group("/admin")
+		admin.Use(authMiddleware.Authenticate(), authMiddleware.RequireAdmin())
+		{
+			admin.GET("/dead-letters/:queue", deadLetterHandler.ListDeadLetters)
+			admin.POST("/dead-letters/:queue/redrive", deadLetterHandler.RedriveDeadLetters)
+			admin.POST("/fx-rates", fxRateHandler.LoadRates)
+			admin.GET("/fx-rates", fxRateHandler.ListRates)
+		}
 	}
 
 	return router
@@ -0,0 +1,29 @@
+package messaging
+
+import (
+	"go-api-streaming/domain/events"
+	"go-api-streaming/infrastructure/messaging"
+	"go-api-streaming/infrastructure/stream"
+)
+
+// TransactionEventConsumer feeds transaction events from RabbitMQ into the
+// in-process stream hub that serves live subscribers on this instance.
+type TransactionEventConsumer struct {
+	hub *stream.Hub
+}
+
+func NewTransactionEventConsumer(hub *stream.Hub) *TransactionEventConsumer {
+	return &TransactionEventConsumer{
+		hub: hub,
+	}
+}
+
+func (h *TransactionEventConsumer) Handle(body []byte) error {
+	envelope, err := events.Decode(body)
+	if err != nil {
+		return messaging.Permanent(err)
+	}
+
+	h.hub.Publish(envelope)
+	return nil
+}
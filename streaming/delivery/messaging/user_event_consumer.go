@@ -0,0 +1,80 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-api-streaming/infrastructure/messaging"
+	"go-api-streaming/usecase"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// userEventPayload mirrors UserEventPayload published by the authentication
+// service on the user_events exchange.
+type userEventPayload struct {
+	UserID    string `json:"userId"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	Timestamp string `json:"timestamp"`
+	Action    string `json:"action"`
+}
+
+type UserEventConsumer struct {
+	useCase usecase.UserUseCase
+}
+
+func NewUserEventConsumer(useCase usecase.UserUseCase) *UserEventConsumer {
+	return &UserEventConsumer{
+		useCase: useCase,
+	}
+}
+
+// OrderingKey keys user events by user ID, so events for the same user are
+// applied in the order they arrive.
+func (h *UserEventConsumer) OrderingKey(body []byte) string {
+	var payload userEventPayload
+	json.Unmarshal(body, &payload)
+	return payload.UserID
+}
+
+// Handle applies a single user.created, user.updated or user.deleted event.
+func (h *UserEventConsumer) Handle(body []byte) error {
+	// Malformed events are dead-lettered: redelivering them cannot succeed.
+	var payload userEventPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return messaging.Permanent(fmt.Errorf("invalid user event: %w", err))
+	}
+
+	userID, err := uuid.Parse(payload.UserID)
+	if err != nil {
+		return messaging.Permanent(fmt.Errorf("invalid user ID %q in user event: %w", payload.UserID, err))
+	}
+
+	// The timestamp orders events for the same user, so an event without a
+	// usable one cannot be applied safely.
+	eventAt, err := time.Parse(time.RFC3339Nano, payload.Timestamp)
+	if err != nil {
+		return messaging.Permanent(fmt.Errorf("invalid timestamp %q in user event: %w", payload.Timestamp, err))
+	}
+
+	ctx := context.Background()
+
+	switch payload.Action {
+	case "created", "updated":
+		err = h.useCase.SyncUser(ctx, userID, payload.Email, payload.Name, eventAt)
+	case "deleted":
+		err = h.useCase.DeleteUser(ctx, userID, eventAt)
+	default:
+		log.Printf("Ignoring user event with unknown action %q for user %s", payload.Action, userID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("✓ User projection %s: %s", payload.Action, userID)
+	return nil
+}
@@ -0,0 +1,103 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"go-api-streaming/infrastructure/messaging"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestUserEventConsumerHandle(t *testing.T) {
+	userID := uuid.MustParse("6f1c2a3e-0d6b-4a8e-9a43-9b6d3f0e2c11")
+
+	tests := []struct {
+		name          string
+		body          string
+		wantCall      string
+		wantEventAt   string
+		wantPermanent bool
+	}{
+		{
+			name:        "created",
+			body:        `{"userId":"` + userID.String() + `","email":"a@example.com","name":"A","timestamp":"2026-10-16T09:00:00.5Z","action":"created"}`,
+			wantCall:    "sync",
+			wantEventAt: "2026-10-16T09:00:00.5Z",
+		},
+		{
+			name:        "deleted",
+			body:        `{"userId":"` + userID.String() + `","timestamp":"2026-10-16T10:00:00+02:00","action":"deleted"}`,
+			wantCall:    "delete",
+			wantEventAt: "2026-10-16T08:00:00Z",
+		},
+		{
+			name: "unknown action",
+			body: `{"userId":"` + userID.String() + `","timestamp":"2026-10-16T09:00:00Z","action":"renamed"}`,
+		},
+		{name: "malformed JSON", body: `{"userId":`, wantPermanent: true},
+		{name: "invalid user ID", body: `{"userId":"42","timestamp":"2026-10-16T09:00:00Z","action":"created"}`, wantPermanent: true},
+		{name: "missing timestamp", body: `{"userId":"` + userID.String() + `","action":"updated"}`, wantPermanent: true},
+		{name: "invalid timestamp", body: `{"userId":"` + userID.String() + `","timestamp":"16/10/2026","action":"deleted"}`, wantPermanent: true},
+	}
+
+	for _, tt := range tests {
+		users := &fakeUserUseCase{}
+		err := NewUserEventConsumer(users).Handle([]byte(tt.body))
+
+		if tt.wantPermanent {
+			var permanent *messaging.PermanentError
+			if !errors.As(err, &permanent) {
+				t.Errorf("%s: Handle error = %v, want a permanent error", tt.name, err)
+			}
+			if users.call != "" {
+				t.Errorf("%s: the projection was changed by %s", tt.name, users.call)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Handle error = %v", tt.name, err)
+			continue
+		}
+
+		if users.call != tt.wantCall {
+			t.Errorf("%s: called %q, want %q", tt.name, users.call, tt.wantCall)
+		}
+		if tt.wantCall == "" {
+			continue
+		}
+		wantEventAt, _ := time.Parse(time.RFC3339Nano, tt.wantEventAt)
+		if users.id != userID || !users.eventAt.Equal(wantEventAt) {
+			t.Errorf("%s: applied for %s at %s, want %s at %s", tt.name, users.id, users.eventAt, userID, wantEventAt)
+		}
+	}
+}
+
+func TestUserEventConsumerRetriesUseCaseErrors(t *testing.T) {
+	users := &fakeUserUseCase{err: errors.New("database unavailable")}
+	body := `{"userId":"6f1c2a3e-0d6b-4a8e-9a43-9b6d3f0e2c11","timestamp":"2026-10-16T09:00:00Z","action":"updated"}`
+
+	err := NewUserEventConsumer(users).Handle([]byte(body))
+	var permanent *messaging.PermanentError
+	if err == nil || errors.As(err, &permanent) {
+		t.Errorf("Handle error = %v, want a retryable error", err)
+	}
+}
+
+type fakeUserUseCase struct {
+	err     error
+	call    string
+	id      uuid.UUID
+	eventAt time.Time
+}
+
+func (u *fakeUserUseCase) SyncUser(ctx context.Context, id uuid.UUID, email, name string, eventAt time.Time) error {
+	u.call, u.id, u.eventAt = "sync", id, eventAt
+	return u.err
+}
+
+func (u *fakeUserUseCase) DeleteUser(ctx context.Context, id uuid.UUID, eventAt time.Time) error {
+	u.call, u.id, u.eventAt = "delete", id, eventAt
+	return u.err
+}
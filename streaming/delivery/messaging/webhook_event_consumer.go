@@ -0,0 +1,29 @@
+package messaging
+
+import (
+	"context"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/infrastructure/messaging"
+	"go-api-streaming/usecase"
+)
+
+// WebhookEventConsumer queues webhook deliveries for transaction events. The
+// deliveries themselves are sent by the webhook dispatcher.
+type WebhookEventConsumer struct {
+	useCase usecase.WebhookUseCase
+}
+
+func NewWebhookEventConsumer(useCase usecase.WebhookUseCase) *WebhookEventConsumer {
+	return &WebhookEventConsumer{
+		useCase: useCase,
+	}
+}
+
+func (h *WebhookEventConsumer) Handle(body []byte) error {
+	envelope, err := events.Decode(body)
+	if err != nil {
+		return messaging.Permanent(err)
+	}
+
+	return h.useCase.EnqueueEvent(context.Background(), envelope, body)
+}
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventLogEntry is a transaction event kept for replay. Sequence increases
+// monotonically in commit order among a user's events, so it can be used as a
+// resume cursor of the user's stream.
+type EventLogEntry struct {
+	Sequence    int64           `json:"sequence"`
+	EventID     uuid.UUID       `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	UserID      uuid.UUID       `json:"user_id"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
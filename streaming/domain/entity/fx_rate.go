@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"go-api-streaming/domain/money"
+	"time"
+)
+
+// FXRate is the rate converting BaseCurrency into QuoteCurrency from
+// EffectiveAt until a later rate for the same pair takes effect.
+type FXRate struct {
+	ID            int64      `json:"id"`
+	BaseCurrency  string     `json:"base_currency"`
+	QuoteCurrency string     `json:"quote_currency"`
+	Rate          money.Rate `json:"rate"`
+	EffectiveAt   time.Time  `json:"effective_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"go-api-streaming/domain/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LedgerAccount is an account of the double-entry ledger: a user's wallet in
+// one currency, or a system account (UserID nil) that balances it.
+type LedgerAccount struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	Name      string     `json:"name"`
+	Currency  string     `json:"currency"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// LedgerEntry is one side of a posting. The entries of a transaction always
+// have equal debit and credit totals. Reversal entries mirror the entries of
+// a transaction that was reversed.
+type LedgerEntry struct {
+	ID            int64        `json:"id"`
+	TransactionID uuid.UUID    `json:"transaction_id"`
+	AccountID     uuid.UUID    `json:"account_id"`
+	Direction     string       `json:"direction"`
+	Amount        money.Amount `json:"amount"`
+	Currency      string       `json:"currency"`
+	Reversal      bool         `json:"reversal"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// Wallet is a user's balance in one currency. Reserved is held by pending
+// withdrawals and purchases; Available is what is left to spend.
+type Wallet struct {
+	Currency  string       `json:"currency"`
+	Balance   money.Amount `json:"balance"`
+	Reserved  money.Amount `json:"reserved"`
+	Available money.Amount `json:"available"`
+}
+
+// Ledger account names. The transfers account clears transfers between
+// users: both legs of a transfer post to it, so it nets to zero. The exchange
+// account of each currency takes the other side of currency exchanges.
+const (
+	LedgerAccountWallet    = "wallet"
+	LedgerAccountExternal  = "external"
+	LedgerAccountRevenue   = "revenue"
+	LedgerAccountTransfers = "transfers"
+	LedgerAccountExchange  = "exchange"
+)
+
+// Ledger entry directions
+const (
+	LedgerDebit  = "debit"
+	LedgerCredit = "credit"
+)
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"go-api-streaming/domain/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schedule creates transactions from a template: once at StartAt, or at
+// every occurrence of Recurrence from StartAt until EndAt.
+type Schedule struct {
+	ID              uuid.UUID    `json:"id"`
+	UserID          uuid.UUID    `json:"user_id"`
+	Amount          money.Amount `json:"amount"`
+	Currency        string       `json:"currency"`
+	TransactionType string       `json:"transaction_type"`
+	Description     *string      `json:"description,omitempty"`
+	// Recurrence is an RRULE such as "FREQ=MONTHLY;INTERVAL=1", or nil for a
+	// schedule that runs once.
+	Recurrence *string    `json:"recurrence,omitempty"`
+	StartAt    time.Time  `json:"start_at"`
+	EndAt      *time.Time `json:"end_at,omitempty"`
+	Status     string     `json:"status"`
+	// Occurrence counts the occurrences before NextRunAt, which is nil once
+	// no occurrence is left.
+	Occurrence        int        `json:"occurrence"`
+	NextRunAt         *time.Time `json:"next_run_at,omitempty"`
+	LastTransactionID *uuid.UUID `json:"last_transaction_id,omitempty"`
+	LastError         *string    `json:"last_error,omitempty"`
+	// RetryAt delays the next attempt at the occurrence due at NextRunAt
+	// after it failed for a reason other than the transaction rules.
+	RetryAt   *time.Time `json:"retry_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Schedule statuses
+const (
+	ScheduleStatusActive    = "active"
+	ScheduleStatusPaused    = "paused"
+	ScheduleStatusCancelled = "cancelled"
+	ScheduleStatusCompleted = "completed"
+)
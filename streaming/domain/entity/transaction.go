@@ -1,21 +1,31 @@
 package entity
 
 import (
+	"go-api-streaming/domain/money"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Transaction struct {
-	ID              uuid.UUID  `json:"id"`
-	UserID          uuid.UUID  `json:"user_id"`
-	Amount          float64    `json:"amount"`
-	Currency        string     `json:"currency"`
-	TransactionType string     `json:"transaction_type"`
-	Status          string     `json:"status"`
-	Description     *string    `json:"description,omitempty"`
-	CreatedAt       time.Time  `json:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at"`
+	ID                  uuid.UUID     `json:"id"`
+	UserID              uuid.UUID     `json:"user_id"`
+	Amount              money.Amount  `json:"amount"`
+	Currency            string        `json:"currency"`
+	TransactionType     string        `json:"transaction_type"`
+	Status              string        `json:"status"`
+	StatusReason        *string       `json:"status_reason,omitempty"`
+	ParentTransactionID *uuid.UUID    `json:"parent_transaction_id,omitempty"`
+	TransferID          *uuid.UUID    `json:"transfer_id,omitempty"`
+	TargetAmount        *money.Amount `json:"target_amount,omitempty"`
+	TargetCurrency      *string       `json:"target_currency,omitempty"`
+	ExchangeRate        *money.Rate   `json:"exchange_rate,omitempty"`
+	Description         *string       `json:"description,omitempty"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+	// Refunds lists the refunds and reversals of this transaction. It is only
+	// filled in when a single transaction is fetched.
+	Refunds []*Transaction `json:"refunds,omitempty"`
 }
 
 // Transaction types
@@ -23,11 +33,37 @@ const (
 	TransactionTypeDeposit  = "deposit"
 	TransactionTypeWithdraw = "withdraw"
 	TransactionTypePurchase = "purchase"
+	// A refund returns all or part of a purchase to the wallet.
+	TransactionTypeRefund = "refund"
+	// A reversal takes all or part of a deposit back out of the wallet.
+	TransactionTypeReversal = "reversal"
+	// The two legs of a transfer between users.
+	TransactionTypeTransferOut = "transfer_out"
+	TransactionTypeTransferIn  = "transfer_in"
+	// An exchange converts Amount in Currency into TargetAmount in
+	// TargetCurrency at ExchangeRate.
+	TransactionTypeExchange = "exchange"
 )
 
 // Transaction statuses
 const (
-	TransactionStatusPending = "pending"
-	TransactionStatusSuccess = "success"
-	TransactionStatusFailed  = "failed"
+	TransactionStatusPending    = "pending"
+	TransactionStatusProcessing = "processing"
+	TransactionStatusSuccess    = "success"
+	TransactionStatusFailed     = "failed"
+	TransactionStatusCancelled  = "cancelled"
+	TransactionStatusReversed   = "reversed"
+	TransactionStatusExpired    = "expired"
+)
+
+// Status reason codes explain why a transaction reached its status
+const (
+	StatusReasonUserRequested     = "user_requested"
+	StatusReasonInsufficientFunds = "insufficient_funds"
+	StatusReasonProviderDeclined  = "provider_declined"
+	StatusReasonProviderError     = "provider_error"
+	StatusReasonFraudSuspected    = "fraud_suspected"
+	StatusReasonChargeback        = "chargeback"
+	StatusReasonDuplicate         = "duplicate"
+	StatusReasonOther             = "other"
 )
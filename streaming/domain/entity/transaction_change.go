@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransactionChange is a row change on the transactions table made outside
+// the use cases (manual SQL, batch jobs), captured by a database trigger.
+type TransactionChange struct {
+	ID             int64      `json:"id"`
+	TransactionID  uuid.UUID  `json:"transaction_id"`
+	Operation      string     `json:"operation"` // "INSERT" or "UPDATE"
+	PreviousStatus *string    `json:"previous_status,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ProcessedAt    *time.Time `json:"processed_at,omitempty"`
+}
+
+// Transaction change operations
+const (
+	TransactionChangeInsert = "INSERT"
+	TransactionChangeUpdate = "UPDATE"
+)
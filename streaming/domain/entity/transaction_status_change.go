@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransactionStatusChange is one row of a transaction's append-only status
+// history. PreviousStatus is nil for the status a transaction was created
+// with, and ActorID is nil for changes made outside the service.
+type TransactionStatusChange struct {
+	ID             int64      `json:"id"`
+	TransactionID  uuid.UUID  `json:"transaction_id"`
+	PreviousStatus *string    `json:"previous_status"`
+	Status         string     `json:"status"`
+	ActorID        *uuid.UUID `json:"actor_id"`
+	Reason         *string    `json:"reason,omitempty"`
+	RequestID      *string    `json:"request_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
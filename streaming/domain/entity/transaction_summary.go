@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"go-api-streaming/domain/money"
+	"time"
+)
+
+// TransactionTotal is the count and sum of the transactions of one type in
+// one currency, with the sum converted to a summary's base currency.
+type TransactionTotal struct {
+	TransactionType string       `json:"transaction_type"`
+	Currency        string       `json:"currency"`
+	Count           int64        `json:"count"`
+	Amount          money.Amount `json:"amount"`
+	ConvertedAmount money.Amount `json:"converted_amount"`
+}
+
+// TransactionSummary totals transactions per type and currency, and per type
+// in BaseCurrency using the rates in effect at RatesAt.
+type TransactionSummary struct {
+	BaseCurrency    string                  `json:"base_currency"`
+	RatesAt         time.Time               `json:"rates_at"`
+	Totals          []*TransactionTotal     `json:"totals"`
+	ConvertedByType map[string]money.Amount `json:"converted_by_type"`
+}
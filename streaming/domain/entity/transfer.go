@@ -0,0 +1,12 @@
+package entity
+
+import "github.com/google/uuid"
+
+// Transfer moves money from one user's wallet to another's. It is recorded as
+// two transactions sharing the transfer ID: a transfer_out for the sender and
+// a transfer_in for the recipient.
+type Transfer struct {
+	ID       uuid.UUID    `json:"id"`
+	Outgoing *Transaction `json:"outgoing"`
+	Incoming *Transaction `json:"incoming"`
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserProjection is the local copy of a user owned by the authentication
+// service, kept up to date from the user_events exchange.
+type UserProjection struct {
+	ID          uuid.UUID `json:"id"`
+	Email       string    `json:"email"`
+	Name        string    `json:"name"`
+	Deleted     bool      `json:"deleted"`
+	LastEventAt time.Time `json:"last_event_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SpecVersion is the CloudEvents specification version of Envelope.
+	SpecVersion = "1.0"
+	// ContentType is the AMQP content type of a structured-mode CloudEvent.
+	ContentType = "application/cloudevents+json"
+	// Producer identifies this service as the source of its events.
+	Producer = "streaming-service"
+)
+
+// Event is a typed event payload.
+type Event interface {
+	// EventType is the CloudEvents type, e.g. "transaction.created".
+	EventType() string
+	// SchemaVersion is bumped on every breaking change to the payload.
+	SchemaVersion() string
+	// AggregateID is the entity the event is about. It becomes the subject.
+	AggregateID() uuid.UUID
+	// RoutingKey is the topic routing key the event is published with.
+	RoutingKey() string
+}
+
+// Envelope wraps an event in the CloudEvents 1.0 JSON format. Schema version,
+// correlation ID and the event log sequence are carried as extension
+// attributes.
+type Envelope struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	SchemaVersion   string          `json:"schemaversion"`
+	CorrelationID   string          `json:"correlationid,omitempty"`
+	Sequence        int64           `json:"sequence,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// NewEnvelope wraps event with a fresh ID and the correlation ID found in ctx.
+func NewEnvelope(ctx context.Context, event Event) (*Envelope, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", event.EventType(), err)
+	}
+
+	return &Envelope{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.New().String(),
+		Source:          Producer,
+		Type:            event.EventType(),
+		Subject:         event.AggregateID().String(),
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		SchemaVersion:   event.SchemaVersion(),
+		CorrelationID:   CorrelationID(ctx),
+		Data:            data,
+	}, nil
+}
+
+// Decode parses a CloudEvents JSON message.
+func Decode(body []byte) (*Envelope, error) {
+	envelope := &Envelope{}
+	if err := json.Unmarshal(body, envelope); err != nil {
+		return nil, fmt.Errorf("invalid event envelope: %w", err)
+	}
+	if envelope.SpecVersion == "" || envelope.Type == "" {
+		return nil, fmt.Errorf("invalid event envelope: missing specversion or type")
+	}
+	return envelope, nil
+}
+
+// DecodeData unmarshals the event payload into v.
+func (e *Envelope) DecodeData(v interface{}) error {
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("invalid %s event data: %w", e.Type, err)
+	}
+	return nil
+}
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a context carrying the correlation ID that events
+// created from it will be stamped with.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationID returns the correlation ID stored in ctx, or "".
+func CorrelationID(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
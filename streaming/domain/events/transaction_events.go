@@ -0,0 +1,100 @@
+package events
+
+import (
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transaction event types
+const (
+	TypeTransactionCreated = "transaction.created"
+	TypeTransactionUpdated = "transaction.updated"
+)
+
+// TransactionSchemaVersion is the schema version of the transaction events.
+// Version 2 carries amounts as decimal strings instead of JSON numbers.
+const TransactionSchemaVersion = "2"
+
+// Transaction is the transaction snapshot carried by transaction events. It is
+// kept separate from entity.Transaction so entity changes do not leak into the
+// published schema.
+type Transaction struct {
+	ID                  uuid.UUID     `json:"id"`
+	UserID              uuid.UUID     `json:"user_id"`
+	Amount              money.Amount  `json:"amount"`
+	Currency            string        `json:"currency"`
+	TransactionType     string        `json:"transaction_type"`
+	Status              string        `json:"status"`
+	StatusReason        *string       `json:"status_reason,omitempty"`
+	ParentTransactionID *uuid.UUID    `json:"parent_transaction_id,omitempty"`
+	TransferID          *uuid.UUID    `json:"transfer_id,omitempty"`
+	TargetAmount        *money.Amount `json:"target_amount,omitempty"`
+	TargetCurrency      *string       `json:"target_currency,omitempty"`
+	ExchangeRate        *money.Rate   `json:"exchange_rate,omitempty"`
+	Description         *string       `json:"description,omitempty"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+}
+
+func NewTransaction(transaction *entity.Transaction) Transaction {
+	return Transaction{
+		ID:                  transaction.ID,
+		UserID:              transaction.UserID,
+		Amount:              transaction.Amount,
+		Currency:            transaction.Currency,
+		TransactionType:     transaction.TransactionType,
+		Status:              transaction.Status,
+		StatusReason:        transaction.StatusReason,
+		ParentTransactionID: transaction.ParentTransactionID,
+		TransferID:          transaction.TransferID,
+		TargetAmount:        transaction.TargetAmount,
+		TargetCurrency:      transaction.TargetCurrency,
+		ExchangeRate:        transaction.ExchangeRate,
+		Description:         transaction.Description,
+		CreatedAt:           transaction.CreatedAt,
+		UpdatedAt:           transaction.UpdatedAt,
+	}
+}
+
+type TransactionCreated struct {
+	Transaction Transaction `json:"transaction"`
+}
+
+func (e TransactionCreated) EventType() string      { return TypeTransactionCreated }
+func (e TransactionCreated) SchemaVersion() string  { return TransactionSchemaVersion }
+func (e TransactionCreated) AggregateID() uuid.UUID { return e.Transaction.ID }
+func (e TransactionCreated) RoutingKey() string     { return TypeTransactionCreated }
+
+type TransactionUpdated struct {
+	Transaction    Transaction `json:"transaction"`
+	PreviousStatus string      `json:"previous_status"`
+}
+
+func (e TransactionUpdated) EventType() string      { return TypeTransactionUpdated }
+func (e TransactionUpdated) SchemaVersion() string  { return TransactionSchemaVersion }
+func (e TransactionUpdated) AggregateID() uuid.UUID { return e.Transaction.ID }
+
+// RoutingKey is "transaction.status.<status>" so subscribers can pick the
+// outcomes they care about.
+func (e TransactionUpdated) RoutingKey() string {
+	return "transaction.status." + e.Transaction.Status
+}
+
+// DecodeTransaction returns the transaction snapshot carried by a
+// transaction.created or transaction.updated envelope.
+func DecodeTransaction(envelope *Envelope) (*Transaction, error) {
+	var data struct {
+		Transaction *Transaction `json:"transaction"`
+	}
+	if err := envelope.DecodeData(&data); err != nil {
+		return nil, err
+	}
+	if data.Transaction == nil {
+		return nil, fmt.Errorf("%s event carries no transaction", envelope.Type)
+	}
+	return data.Transaction, nil
+}
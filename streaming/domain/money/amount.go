@@ -0,0 +1,300 @@
+// Package money provides an exact decimal amount type and currency metadata,
+// so amounts never pass through binary floating point.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of fractional digits an Amount can hold. It covers
+// every ISO 4217 currency and matches the NUMERIC(18, 4) amount columns.
+const Scale = 4
+
+// unit is the integer value of 1 at Scale.
+const unit = 10000
+
+// Amount is an exact decimal amount with up to Scale fractional digits,
+// stored as an integer count of 10^-Scale. The zero value is 0.
+//
+// Amounts are serialised as JSON strings, e.g. "100.5", to keep clients from
+// parsing them as floats. JSON numbers are accepted on input as well.
+type Amount struct {
+	v int64
+}
+
+var (
+	// ErrInvalidAmount is returned for text that is not a plain decimal.
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrAmountOutOfRange is returned for amounts too large to represent.
+	ErrAmountOutOfRange = errors.New("amount out of range")
+)
+
+// Zero is the zero amount.
+var Zero = Amount{}
+
+// MaxAmount is the largest amount the NUMERIC(18, 4) columns can store,
+// 99999999999999.9999.
+var MaxAmount = Amount{v: 1e18 - 1}
+
+// New returns the amount units * 10^-decimals, e.g. New(1050, 2) is 10.50.
+// decimals must be between 0 and Scale.
+func New(units int64, decimals int) Amount {
+	if decimals < 0 || decimals > Scale {
+		panic(fmt.Sprintf("money: decimals %d out of range", decimals))
+	}
+	return Amount{v: units * pow10(Scale-decimals)}
+}
+
+// Parse parses a plain decimal such as "12", "-0.5" or "1000.2500". Exponents
+// and more than Scale fractional digits are rejected.
+func Parse(s string) (Amount, error) {
+	v, err := parseDecimal(s, Scale)
+	if err != nil {
+		return Zero, err
+	}
+	return Amount{v: v}, nil
+}
+
+// MustParse is like Parse but panics on error. It is meant for constants.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// String returns the shortest exact decimal form, e.g. "100.5" or "-3".
+func (a Amount) String() string {
+	return trimDecimal(a.StringFixed(Scale))
+}
+
+// StringFixed formats the amount with exactly decimals fractional digits,
+// which must not drop significant digits (see Decimals).
+func (a Amount) StringFixed(decimals int) string {
+	return formatDecimal(a.v, Scale, decimals)
+}
+
+// Decimals returns the number of significant fractional digits.
+func (a Amount) Decimals() int {
+	frac := a.v % unit
+	if frac < 0 {
+		frac = -frac
+	}
+	decimals := Scale
+	for decimals > 0 && frac%10 == 0 {
+		frac /= 10
+		decimals--
+	}
+	return decimals
+}
+
+// Add returns a + b, or ErrAmountOutOfRange if the sum does not fit.
+func (a Amount) Add(b Amount) (Amount, error) {
+	sum := a.v + b.v
+	if (b.v > 0 && sum < a.v) || (b.v < 0 && sum > a.v) {
+		return Zero, fmt.Errorf("%w: %s + %s", ErrAmountOutOfRange, a, b)
+	}
+	return Amount{v: sum}, nil
+}
+
+// Sub returns a - b, or ErrAmountOutOfRange if the difference does not fit.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	diff := a.v - b.v
+	if (b.v > 0 && diff > a.v) || (b.v < 0 && diff < a.v) {
+		return Zero, fmt.Errorf("%w: %s - %s", ErrAmountOutOfRange, a, b)
+	}
+	return Amount{v: diff}, nil
+}
+
+func (a Amount) Neg() Amount { return Amount{v: -a.v} }
+
+// Cmp returns -1, 0 or 1 as a is less than, equal to or greater than b.
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a.v < b.v:
+		return -1
+	case a.v > b.v:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (a Amount) IsZero() bool     { return a.v == 0 }
+func (a Amount) IsPositive() bool { return a.v > 0 }
+func (a Amount) IsNegative() bool { return a.v < 0 }
+
+// MarshalJSON encodes the amount as a JSON string.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON accepts a JSON string or number. Numbers are parsed from
+// their literal text, so no precision is lost on the way in either.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value stores the amount as a decimal string, which PostgreSQL converts to
+// NUMERIC without rounding.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan reads a NUMERIC column.
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return a.scanText(string(v))
+	case string:
+		return a.scanText(v)
+	case int64:
+		if v > math.MaxInt64/unit || v < math.MinInt64/unit {
+			return ErrAmountOutOfRange
+		}
+		*a = Amount{v: v * unit}
+		return nil
+	case nil:
+		*a = Zero
+		return nil
+	default:
+		return fmt.Errorf("money: cannot scan %T into Amount", src)
+	}
+}
+
+func (a *Amount) scanText(text string) error {
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// parseDecimal parses a plain decimal into an integer count of 10^-scale.
+func parseDecimal(s string, scale int) (int64, error) {
+	text := strings.TrimSpace(s)
+
+	negative := false
+	switch {
+	case strings.HasPrefix(text, "-"):
+		negative = true
+		text = text[1:]
+	case strings.HasPrefix(text, "+"):
+		text = text[1:]
+	}
+
+	whole, frac, hasPoint := strings.Cut(text, ".")
+	if whole == "" && frac == "" || hasPoint && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	// Trailing zeros do not add precision.
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > scale {
+		return 0, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, s, scale)
+	}
+
+	one := pow10(scale)
+	var wholeValue int64
+	if whole != "" {
+		n, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil || n > math.MaxInt64/one {
+			return 0, fmt.Errorf("%w: %q", ErrAmountOutOfRange, s)
+		}
+		wholeValue = n * one
+	}
+
+	var fracValue int64
+	if frac != "" {
+		n, _ := strconv.ParseInt(frac, 10, 64)
+		fracValue = n * pow10(scale-len(frac))
+	}
+
+	if wholeValue > math.MaxInt64-fracValue {
+		return 0, fmt.Errorf("%w: %q", ErrAmountOutOfRange, s)
+	}
+
+	value := wholeValue + fracValue
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+// formatDecimal formats v, a count of 10^-scale, with exactly decimals
+// fractional digits.
+func formatDecimal(v int64, scale, decimals int) string {
+	sign := ""
+	if v < 0 {
+		sign = "-"
+	}
+
+	// Work on the magnitude as unsigned so math.MinInt64 cannot overflow.
+	magnitude := uint64(v)
+	if v < 0 {
+		magnitude = uint64(-(v + 1)) + 1
+	}
+
+	one := uint64(pow10(scale))
+	whole := magnitude / one
+	frac := fmt.Sprintf("%0*d", scale, magnitude%one)
+	if decimals <= 0 {
+		return sign + strconv.FormatUint(whole, 10)
+	}
+	if decimals < scale {
+		frac = frac[:decimals]
+	}
+	for len(frac) < decimals {
+		frac += "0"
+	}
+	return sign + strconv.FormatUint(whole, 10) + "." + frac
+}
+
+// trimDecimal drops trailing fractional zeros and a trailing point.
+func trimDecimal(s string) string {
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
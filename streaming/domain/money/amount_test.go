@@ -0,0 +1,234 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr error
+	}{
+		{in: "12", want: "12"},
+		{in: "-0.5", want: "-0.5"},
+		{in: "+3.10", want: "3.1"},
+		{in: "1000.2500", want: "1000.25"},
+		{in: ".5", want: "0.5"},
+		{in: " 7 ", want: "7"},
+		{in: "0.00000", want: "0"},
+		{in: "1.23450", want: "1.2345"},
+		{in: "99999999999999.9999", want: "99999999999999.9999"},
+		{in: "", wantErr: ErrInvalidAmount},
+		{in: "-", wantErr: ErrInvalidAmount},
+		{in: "5.", wantErr: ErrInvalidAmount},
+		{in: "1e3", wantErr: ErrInvalidAmount},
+		{in: "1,5", wantErr: ErrInvalidAmount},
+		{in: "0x10", wantErr: ErrInvalidAmount},
+		{in: "1.23456", wantErr: ErrInvalidAmount},
+		{in: "922337203685477.5808", wantErr: ErrAmountOutOfRange},
+		{in: "9223372036854775807", wantErr: ErrAmountOutOfRange},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStringFixed(t *testing.T) {
+	tests := []struct {
+		amount   Amount
+		decimals int
+		want     string
+	}{
+		{amount: MustParse("10.5"), decimals: 2, want: "10.50"},
+		{amount: MustParse("3"), decimals: 0, want: "3"},
+		{amount: MustParse("-0.25"), decimals: 4, want: "-0.2500"},
+		{amount: MustParse("0.001"), decimals: 3, want: "0.001"},
+		{amount: Zero, decimals: 2, want: "0.00"},
+		{amount: MaxAmount, decimals: 4, want: "99999999999999.9999"},
+		{amount: Amount{v: math.MinInt64}, decimals: 4, want: "-922337203685477.5808"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.StringFixed(tt.decimals); got != tt.want {
+			t.Errorf("%s.StringFixed(%d) = %s, want %s", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		units    int64
+		decimals int
+		want     string
+	}{
+		{units: 1050, decimals: 2, want: "10.5"},
+		{units: 7, decimals: 0, want: "7"},
+		{units: -1, decimals: 4, want: "-0.0001"},
+	}
+
+	for _, tt := range tests {
+		if got := New(tt.units, tt.decimals).String(); got != tt.want {
+			t.Errorf("New(%d, %d) = %s, want %s", tt.units, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestDecimals(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{in: "100", want: 0},
+		{in: "100.5", want: 1},
+		{in: "-0.25", want: 2},
+		{in: "0.0001", want: 4},
+	}
+
+	for _, tt := range tests {
+		if got := MustParse(tt.in).Decimals(); got != tt.want {
+			t.Errorf("Decimals(%s) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMaxAmount(t *testing.T) {
+	if got := MustParse("99999999999999.9999").Cmp(MaxAmount); got != 0 {
+		t.Errorf("Cmp(largest NUMERIC(18, 4), MaxAmount) = %d, want 0", got)
+	}
+	if got := MustParse("100000000000000").Cmp(MaxAmount); got != 1 {
+		t.Errorf("Cmp(one above MaxAmount, MaxAmount) = %d, want 1", got)
+	}
+
+	// The sum of two maximum amounts still fits in an Amount, so callers
+	// must check it against MaxAmount themselves.
+	sum, err := MaxAmount.Add(MaxAmount)
+	if err != nil {
+		t.Fatalf("MaxAmount + MaxAmount error = %v", err)
+	}
+	if sum.Cmp(MaxAmount) != 1 {
+		t.Errorf("MaxAmount + MaxAmount = %s, want above MaxAmount", sum)
+	}
+}
+
+func TestAddSub(t *testing.T) {
+	largest := Amount{v: math.MaxInt64}
+	smallest := Amount{v: math.MinInt64}
+	tiny := New(1, Scale)
+
+	tests := []struct {
+		name    string
+		op      func() (Amount, error)
+		want    string
+		wantErr bool
+	}{
+		{name: "add", op: func() (Amount, error) { return MustParse("1.25").Add(MustParse("2.5")) }, want: "3.75"},
+		{name: "add negative", op: func() (Amount, error) { return MustParse("1").Add(MustParse("-2.5")) }, want: "-1.5"},
+		{name: "sub", op: func() (Amount, error) { return MustParse("1").Sub(MustParse("0.0001")) }, want: "0.9999"},
+		{name: "sub negative", op: func() (Amount, error) { return MustParse("1").Sub(MustParse("-1")) }, want: "2"},
+		{name: "add overflow", op: func() (Amount, error) { return largest.Add(tiny) }, wantErr: true},
+		{name: "add underflow", op: func() (Amount, error) { return smallest.Add(tiny.Neg()) }, wantErr: true},
+		{name: "sub overflow", op: func() (Amount, error) { return largest.Sub(tiny.Neg()) }, wantErr: true},
+		{name: "sub underflow", op: func() (Amount, error) { return smallest.Sub(tiny) }, wantErr: true},
+		{name: "sub at limit", op: func() (Amount, error) { return largest.Sub(tiny) }, want: "922337203685477.5806"},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.op()
+		if tt.wantErr {
+			if !errors.Is(err, ErrAmountOutOfRange) {
+				t.Errorf("%s: error = %v, want ErrAmountOutOfRange", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: `"100.50"`, want: `"100.5"`},
+		{in: `0.1`, want: `"0.1"`},
+		{in: `-3`, want: `"-3"`},
+	}
+
+	for _, tt := range tests {
+		var amount Amount
+		if err := json.Unmarshal([]byte(tt.in), &amount); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.in, err)
+			continue
+		}
+		got, err := json.Marshal(amount)
+		if err != nil {
+			t.Errorf("Marshal(%s) error = %v", amount, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("round trip of %s = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	var amount Amount
+	if err := json.Unmarshal([]byte(`"1.00001"`), &amount); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Unmarshal of too many decimals error = %v, want ErrInvalidAmount", err)
+	}
+}
+
+func TestAmountScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    string
+		wantErr bool
+	}{
+		{src: []byte("12.3400"), want: "12.34"},
+		{src: "0.5", want: "0.5"},
+		{src: int64(42), want: "42"},
+		{src: nil, want: "0"},
+		{src: int64(math.MaxInt64), wantErr: true},
+		{src: 1.5, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var amount Amount
+		err := amount.Scan(tt.src)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Scan(%v) = %s, want an error", tt.src, amount)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Scan(%v) error = %v", tt.src, err)
+			continue
+		}
+		if amount.String() != tt.want {
+			t.Errorf("Scan(%v) = %s, want %s", tt.src, amount, tt.want)
+		}
+	}
+}
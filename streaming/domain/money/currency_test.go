@@ -0,0 +1,29 @@
+package money
+
+import "testing"
+
+func TestCurrency(t *testing.T) {
+	tests := []struct {
+		code       string
+		isCurrency bool
+		minorUnits int
+	}{
+		{code: "USD", isCurrency: true, minorUnits: 2},
+		{code: "VND", isCurrency: true, minorUnits: 0},
+		{code: "JPY", isCurrency: true, minorUnits: 0},
+		{code: "KWD", isCurrency: true, minorUnits: 3},
+		{code: "CLF", isCurrency: true, minorUnits: 4},
+		{code: "usd", isCurrency: false, minorUnits: 2},
+		{code: "XAU", isCurrency: false, minorUnits: DefaultMinorUnits},
+		{code: "", isCurrency: false, minorUnits: DefaultMinorUnits},
+	}
+
+	for _, tt := range tests {
+		if got := IsCurrency(tt.code); got != tt.isCurrency {
+			t.Errorf("IsCurrency(%q) = %t, want %t", tt.code, got, tt.isCurrency)
+		}
+		if got := MinorUnits(tt.code); got != tt.minorUnits {
+			t.Errorf("MinorUnits(%q) = %d, want %d", tt.code, got, tt.minorUnits)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RateScale is the number of fractional digits a Rate can hold. It matches
+// the NUMERIC(19, 10) rate columns.
+const RateScale = 10
+
+// ErrInvalidRate is returned for exchange rates that are not positive
+// decimals.
+var ErrInvalidRate = errors.New("invalid exchange rate")
+
+// Rate is an exchange rate: how much of the quote currency one unit of the
+// base currency buys. Like Amount it is exact, stored as an integer count of
+// 10^-RateScale, and serialised as a JSON string.
+type Rate struct {
+	v int64
+}
+
+// RateOne converts a currency into itself.
+var RateOne = Rate{v: pow10(RateScale)}
+
+// ParseRate parses a positive plain decimal with up to RateScale fractional
+// digits, e.g. "25345.5" or "0.0000394".
+func ParseRate(s string) (Rate, error) {
+	v, err := parseDecimal(s, RateScale)
+	if err != nil {
+		return Rate{}, fmt.Errorf("%w: %v", ErrInvalidRate, err)
+	}
+	if v <= 0 {
+		return Rate{}, fmt.Errorf("%w: %q is not positive", ErrInvalidRate, s)
+	}
+	return Rate{v: v}, nil
+}
+
+// String returns the shortest exact decimal form.
+func (r Rate) String() string {
+	return trimDecimal(formatDecimal(r.v, RateScale, RateScale))
+}
+
+// IsPositive reports whether r can be used for conversions. The zero Rate is
+// not.
+func (r Rate) IsPositive() bool { return r.v > 0 }
+
+// Convert returns a converted at r, rounded half away from zero to decimals
+// fractional digits.
+func (r Rate) Convert(a Amount, decimals int) (Amount, error) {
+	if decimals < 0 || decimals > Scale {
+		return Zero, fmt.Errorf("money: decimals %d out of range", decimals)
+	}
+
+	// a.v * r.v is a count of 10^-(Scale+RateScale).
+	product := new(big.Int).Mul(big.NewInt(a.v), big.NewInt(r.v))
+	rounded := roundDiv(product, bigPow10(Scale+RateScale-decimals))
+	rounded.Mul(rounded, bigPow10(Scale-decimals))
+	if !rounded.IsInt64() {
+		return Zero, ErrAmountOutOfRange
+	}
+	return Amount{v: rounded.Int64()}, nil
+}
+
+// Invert returns the rate converting the other way, rounded half away from
+// zero to RateScale digits.
+func (r Rate) Invert() (Rate, error) {
+	if r.v <= 0 {
+		return Rate{}, fmt.Errorf("%w: cannot invert %s", ErrInvalidRate, r)
+	}
+
+	inverted := roundDiv(bigPow10(2*RateScale), big.NewInt(r.v))
+	if !inverted.IsInt64() || inverted.Sign() == 0 {
+		return Rate{}, fmt.Errorf("%w: inverse of %s is out of range", ErrInvalidRate, r)
+	}
+	return Rate{v: inverted.Int64()}, nil
+}
+
+// MarshalJSON encodes the rate as a JSON string.
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON accepts a JSON string or number.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Value stores the rate as a decimal string.
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan reads a NUMERIC column.
+func (r *Rate) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case nil:
+		*r = Rate{}
+		return nil
+	default:
+		return fmt.Errorf("money: cannot scan %T into Rate", src)
+	}
+
+	parsed, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// roundDiv returns n / d rounded half away from zero. d must be positive.
+func roundDiv(n, d *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(n, d, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(d) >= 0 {
+		if n.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient
+}
+
+func bigPow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
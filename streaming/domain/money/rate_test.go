@@ -0,0 +1,129 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "25345.5", want: "25345.5"},
+		{in: "0.0000394", want: "0.0000394"},
+		{in: "1.0000000000", want: "1"},
+		{in: "0.0000000001", want: "0.0000000001"},
+		{in: "0", wantErr: true},
+		{in: "-1.5", wantErr: true},
+		{in: "0.00000000001", wantErr: true},
+		{in: "30000000000", wantErr: true},
+		{in: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidRate) {
+				t.Errorf("ParseRate(%q) error = %v, want ErrInvalidRate", tt.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRate(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseRate(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRateConvert(t *testing.T) {
+	tests := []struct {
+		amount   string
+		rate     string
+		decimals int
+		want     string
+		wantErr  error
+	}{
+		{amount: "100", rate: "25345", decimals: 0, want: "2534500"},
+		{amount: "1.5", rate: "25345", decimals: 0, want: "38018"},
+		{amount: "2534500", rate: "0.0000394555", decimals: 2, want: "100"},
+		{amount: "0.01", rate: "0.5", decimals: 2, want: "0.01"},
+		{amount: "0.01", rate: "0.4", decimals: 2, want: "0"},
+		{amount: "-0.01", rate: "0.5", decimals: 2, want: "-0.01"},
+		{amount: "10", rate: "0.333", decimals: 3, want: "3.33"},
+		{amount: "1", rate: "1.23456789", decimals: 4, want: "1.2346"},
+		{amount: "922337203685477", rate: "2", decimals: 2, wantErr: ErrAmountOutOfRange},
+	}
+
+	for _, tt := range tests {
+		got, err := mustParseRate(t, tt.rate).Convert(MustParse(tt.amount), tt.decimals)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Convert(%s at %s) error = %v, want %v", tt.amount, tt.rate, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Convert(%s at %s) error = %v", tt.amount, tt.rate, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Convert(%s at %s, %d) = %s, want %s", tt.amount, tt.rate, tt.decimals, got, tt.want)
+		}
+	}
+
+	if _, err := RateOne.Convert(MustParse("1"), Scale+1); err == nil {
+		t.Errorf("Convert with %d decimals succeeded, want an error", Scale+1)
+	}
+}
+
+func TestRateInvert(t *testing.T) {
+	tests := []struct {
+		rate    string
+		want    string
+		wantErr bool
+	}{
+		{rate: "4", want: "0.25"},
+		{rate: "1", want: "1"},
+		{rate: "3", want: "0.3333333333"},
+		{rate: "0.6", want: "1.6666666667"},
+		{rate: "25345", want: "0.0000394555"},
+		{rate: "0.001", want: "1000"},
+		{rate: "0.0000000001", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := mustParseRate(t, tt.rate).Invert()
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidRate) {
+				t.Errorf("Invert(%s) error = %v, want ErrInvalidRate", tt.rate, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Invert(%s) error = %v", tt.rate, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Invert(%s) = %s, want %s", tt.rate, got, tt.want)
+		}
+	}
+
+	if _, err := (Rate{}).Invert(); !errors.Is(err, ErrInvalidRate) {
+		t.Errorf("Invert of the zero rate error = %v, want ErrInvalidRate", err)
+	}
+}
+
+// mustParseRate parses a rate of a test case, failing the test on error.
+func mustParseRate(t *testing.T, s string) Rate {
+	t.Helper()
+	rate, err := ParseRate(s)
+	if err != nil {
+		t.Fatalf("ParseRate(%q) error = %v", s, err)
+	}
+	return rate
+}
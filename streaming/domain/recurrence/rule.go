@@ -0,0 +1,309 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule is returned for recurrence rules that cannot be parsed.
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Recurrence frequencies
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// maxPeriods bounds the periods Next looks through for an occurrence. A rule
+// such as "FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=31" started in April never
+// occurs; every rule that does occur repeats its pattern well within it.
+const maxPeriods = 1000
+
+// weekdayCodes are the RRULE codes of the weekdays, indexed by time.Weekday.
+var weekdayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Rule is the subset of an RFC 5545 RRULE made of a frequency, an interval
+// and, for weekly and monthly rules, the days they run on, e.g.
+// "FREQ=MONTHLY;INTERVAL=3" for every three months or
+// "FREQ=WEEKLY;BYDAY=MO,TH" for every Monday and Thursday. Occurrences repeat
+// the time of day of the first one and, without BYDAY or BYMONTHDAY, its
+// weekday or day of month.
+type Rule struct {
+	Freq     string
+	Interval int
+	// ByDay lists the weekdays a weekly rule runs on, Monday first.
+	ByDay []time.Weekday
+	// ByMonthDay lists the days of the month a monthly rule runs on, in
+	// ascending order. Negative days count from the end of the month, -1
+	// being its last day.
+	ByMonthDay []int
+}
+
+// Parse parses a rule such as "FREQ=WEEKLY" or "RRULE:FREQ=DAILY;INTERVAL=2".
+// INTERVAL defaults to 1. BYDAY is only allowed with FREQ=WEEKLY and
+// BYMONTHDAY only with FREQ=MONTHLY.
+func Parse(s string) (Rule, error) {
+	rule := Rule{Interval: 1}
+
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("%w: %q is not a KEY=VALUE pair", ErrInvalidRule, part)
+		}
+
+		switch key {
+		case "FREQ":
+			switch value {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = value
+			default:
+				return Rule{}, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRule, value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return Rule{}, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRule)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			days, err := parseByDay(value)
+			if err != nil {
+				return Rule{}, err
+			}
+			rule.ByDay = days
+		case "BYMONTHDAY":
+			days, err := parseByMonthDay(value)
+			if err != nil {
+				return Rule{}, err
+			}
+			rule.ByMonthDay = days
+		default:
+			return Rule{}, fmt.Errorf("%w: unsupported part %q", ErrInvalidRule, key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if rule.ByDay != nil && rule.Freq != Weekly {
+		return Rule{}, fmt.Errorf("%w: BYDAY is only supported with FREQ=WEEKLY", ErrInvalidRule)
+	}
+	if rule.ByMonthDay != nil && rule.Freq != Monthly {
+		return Rule{}, fmt.Errorf("%w: BYMONTHDAY is only supported with FREQ=MONTHLY", ErrInvalidRule)
+	}
+
+	return rule, nil
+}
+
+// parseByDay parses a list of weekday codes such as "MO,WE,FR".
+func parseByDay(value string) ([]time.Weekday, error) {
+	seen := make(map[time.Weekday]bool)
+	for _, code := range strings.Split(value, ",") {
+		found := false
+		for day, dayCode := range weekdayCodes {
+			if code == dayCode {
+				seen[time.Weekday(day)] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: unsupported BYDAY value %q", ErrInvalidRule, code)
+		}
+	}
+
+	days := make([]time.Weekday, 0, len(seen))
+	for day := range seen {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return daysSinceMonday(days[i]) < daysSinceMonday(days[j])
+	})
+
+	return days, nil
+}
+
+// parseByMonthDay parses a list of days of the month such as "1,15,-1".
+func parseByMonthDay(value string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(part)
+		if err != nil || day == 0 || day < -31 || day > 31 {
+			return nil, fmt.Errorf("%w: BYMONTHDAY values must be between 1 and 31 or -31 and -1", ErrInvalidRule)
+		}
+		seen[day] = true
+	}
+
+	days := make([]int, 0, len(seen))
+	for day := range seen {
+		days = append(days, day)
+	}
+	sort.Ints(days)
+
+	return days, nil
+}
+
+// String returns the rule in the form Parse accepts.
+func (r Rule) String() string {
+	s := fmt.Sprintf("FREQ=%s;INTERVAL=%d", r.Freq, r.Interval)
+
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = weekdayCodes[day]
+		}
+		s += ";BYDAY=" + strings.Join(codes, ",")
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		s += ";BYMONTHDAY=" + strings.Join(days, ",")
+	}
+
+	return s
+}
+
+// First returns the first occurrence of a rule started at start, which is
+// start itself unless BYDAY or BYMONTHDAY exclude its day.
+func (r Rule) First(start time.Time) (time.Time, bool) {
+	return r.Next(start, start.Add(-time.Nanosecond))
+}
+
+// Next returns the first occurrence of a rule started at start that falls
+// after after, and false if the rule never occurs again.
+//
+// Monthly and yearly occurrences without BYMONTHDAY that fall on a day the
+// month does not have move to its last day, so a rule starting on January 31
+// runs on the last day of February and on March 31. A BYMONTHDAY the month
+// does not have is skipped, as RFC 5545 requires.
+func (r Rule) Next(start, after time.Time) (time.Time, bool) {
+	first := 0
+	if after.After(start) {
+		// Start a period early: the estimate ignores clock and DST shifts.
+		first = r.periodsBetween(start, after) - 1
+		if first < 0 {
+			first = 0
+		}
+	}
+
+	for period := first; period < first+maxPeriods; period++ {
+		for _, candidate := range r.candidates(start, period) {
+			if !candidate.Before(start) && candidate.After(after) {
+				return candidate, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// periodsBetween estimates how many whole periods of the rule lie between
+// start and t.
+func (r Rule) periodsBetween(start, t time.Time) int {
+	switch r.Freq {
+	case Daily:
+		return int(t.Sub(start).Hours()/24) / r.Interval
+	case Weekly:
+		return int(t.Sub(start).Hours()/(24*7)) / r.Interval
+	case Monthly:
+		return monthsBetween(start, t) / r.Interval
+	default:
+		return monthsBetween(start, t) / 12 / r.Interval
+	}
+}
+
+// candidates returns the occurrences in the given period of a rule started
+// at start, in chronological order. Some may fall before start.
+func (r Rule) candidates(start time.Time, period int) []time.Time {
+	steps := period * r.Interval
+
+	switch r.Freq {
+	case Daily:
+		return []time.Time{start.AddDate(0, 0, steps)}
+	case Weekly:
+		anchor := start.AddDate(0, 0, 7*steps)
+		if len(r.ByDay) == 0 {
+			return []time.Time{anchor}
+		}
+
+		monday := anchor.AddDate(0, 0, -daysSinceMonday(anchor.Weekday()))
+		times := make([]time.Time, len(r.ByDay))
+		for i, day := range r.ByDay {
+			times[i] = monday.AddDate(0, 0, daysSinceMonday(day))
+		}
+		return times
+	case Monthly:
+		if len(r.ByMonthDay) == 0 {
+			return []time.Time{addMonths(start, steps)}
+		}
+		return monthDays(start, steps, r.ByMonthDay)
+	default:
+		return []time.Time{addMonths(start, 12*steps)}
+	}
+}
+
+// monthDays returns the given days of the month months after start's, at
+// start's time of day, in chronological order. Days the month does not have
+// are left out.
+func monthDays(start time.Time, months int, byMonthDay []int) []time.Time {
+	year, month, _ := start.Date()
+	hour, min, sec := start.Clock()
+	month += time.Month(months)
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, start.Location()).Day()
+
+	var days []int
+	for _, day := range byMonthDay {
+		if day < 0 {
+			day = lastDay + day + 1
+		}
+		if day >= 1 && day <= lastDay {
+			days = append(days, day)
+		}
+	}
+	sort.Ints(days)
+
+	times := make([]time.Time, 0, len(days))
+	for i, day := range days {
+		// -1 and 31 may both resolve to the 31st.
+		if i > 0 && day == days[i-1] {
+			continue
+		}
+		times = append(times, time.Date(year, month, day, hour, min, sec, start.Nanosecond(), start.Location()))
+	}
+
+	return times
+}
+
+// addMonths adds months to t, clamping the day to the end of the month.
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+
+	// Day 0 of the following month is the last day of the target month.
+	lastDay := time.Date(year, month+time.Month(months)+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(year, month+time.Month(months), day, hour, min, sec, t.Nanosecond(), t.Location())
+}
+
+// monthsBetween returns the number of calendar months from from's month to
+// to's.
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// daysSinceMonday returns the position of day in a week starting on Monday.
+func daysSinceMonday(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
@@ -0,0 +1,199 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "FREQ=MONTHLY", want: "FREQ=MONTHLY;INTERVAL=1"},
+		{in: "rrule:freq=daily;interval=2", want: "FREQ=DAILY;INTERVAL=2"},
+		{in: " FREQ=YEARLY;INTERVAL=1 ", want: "FREQ=YEARLY;INTERVAL=1"},
+		{in: "FREQ=WEEKLY;BYDAY=FR,MO,WE,MO", want: "FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,WE,FR"},
+		{in: "FREQ=WEEKLY;BYDAY=SU,SA", want: "FREQ=WEEKLY;INTERVAL=1;BYDAY=SA,SU"},
+		{in: "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=-1,15,1", want: "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=-1,1,15"},
+		{in: "", wantErr: true},
+		{in: "INTERVAL=2", wantErr: true},
+		{in: "FREQ=HOURLY", wantErr: true},
+		{in: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{in: "FREQ=DAILY;INTERVAL=x", wantErr: true},
+		{in: "FREQ=DAILY;COUNT=3", wantErr: true},
+		{in: "FREQ=DAILY;", wantErr: true},
+		{in: "FREQ=DAILY;BYDAY=MO", wantErr: true},
+		{in: "FREQ=MONTHLY;BYDAY=1MO", wantErr: true},
+		{in: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{in: "FREQ=WEEKLY;BYMONTHDAY=1", wantErr: true},
+		{in: "FREQ=MONTHLY;BYMONTHDAY=0", wantErr: true},
+		{in: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: true},
+		{in: "FREQ=MONTHLY;BYMONTHDAY=-32", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidRule", tt.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+
+		// The normalised form parses back to itself.
+		again, err := Parse(rule.String())
+		if err != nil || again.String() != rule.String() {
+			t.Errorf("Parse(%q) = %v, %v, want %s", rule, again, err, rule)
+		}
+	}
+}
+
+func TestNextSequence(t *testing.T) {
+	tests := []struct {
+		rule  string
+		start string
+		want  []string
+	}{
+		{
+			rule:  "FREQ=DAILY;INTERVAL=2",
+			start: "2026-02-27T09:00:00Z",
+			want:  []string{"2026-02-27T09:00:00Z", "2026-03-01T09:00:00Z", "2026-03-03T09:00:00Z"},
+		},
+		{
+			rule:  "FREQ=WEEKLY",
+			start: "2026-12-28T08:30:00Z",
+			want:  []string{"2026-12-28T08:30:00Z", "2027-01-04T08:30:00Z", "2027-01-11T08:30:00Z"},
+		},
+		{
+			// The day is clamped to shorter months, but not carried over.
+			rule:  "FREQ=MONTHLY",
+			start: "2026-01-31T09:00:00Z",
+			want:  []string{"2026-01-31T09:00:00Z", "2026-02-28T09:00:00Z", "2026-03-31T09:00:00Z", "2026-04-30T09:00:00Z", "2026-05-31T09:00:00Z"},
+		},
+		{
+			rule:  "FREQ=MONTHLY;INTERVAL=3",
+			start: "2026-11-30T00:00:00Z",
+			want:  []string{"2026-11-30T00:00:00Z", "2027-02-28T00:00:00Z", "2027-05-30T00:00:00Z"},
+		},
+		{
+			rule:  "FREQ=YEARLY",
+			start: "2028-02-29T12:00:00Z",
+			want:  []string{"2028-02-29T12:00:00Z", "2029-02-28T12:00:00Z", "2030-02-28T12:00:00Z"},
+		},
+		{
+			// Starting on a Wednesday, the Monday of the first week has
+			// already passed.
+			rule:  "FREQ=WEEKLY;BYDAY=MO,TH",
+			start: "2026-10-14T09:00:00Z",
+			want:  []string{"2026-10-15T09:00:00Z", "2026-10-19T09:00:00Z", "2026-10-22T09:00:00Z", "2026-10-26T09:00:00Z"},
+		},
+		{
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU",
+			start: "2026-10-12T09:00:00Z",
+			want:  []string{"2026-10-18T09:00:00Z", "2026-11-01T09:00:00Z", "2026-11-15T09:00:00Z"},
+		},
+		{
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=1",
+			start: "2026-10-16T09:00:00Z",
+			want:  []string{"2026-11-01T09:00:00Z", "2026-12-01T09:00:00Z", "2027-01-01T09:00:00Z"},
+		},
+		{
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: "2027-01-01T00:00:00Z",
+			want:  []string{"2027-01-31T00:00:00Z", "2027-02-28T00:00:00Z", "2027-03-31T00:00:00Z", "2027-04-30T00:00:00Z"},
+		},
+		{
+			// Months without a 31st are skipped.
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=31",
+			start: "2027-01-01T00:00:00Z",
+			want:  []string{"2027-01-31T00:00:00Z", "2027-03-31T00:00:00Z", "2027-05-31T00:00:00Z", "2027-07-31T00:00:00Z", "2027-08-31T00:00:00Z"},
+		},
+		{
+			// -1 and 31 fall on the same day in long months.
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=15,31,-1",
+			start: "2027-01-20T00:00:00Z",
+			want:  []string{"2027-01-31T00:00:00Z", "2027-02-15T00:00:00Z", "2027-02-28T00:00:00Z", "2027-03-15T00:00:00Z", "2027-03-31T00:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.rule, err)
+		}
+		start := mustTime(t, tt.start)
+
+		got, ok := rule.First(start)
+		for i, want := range tt.want {
+			if !ok {
+				t.Errorf("%s from %s: occurrence %d missing, want %s", tt.rule, tt.start, i, want)
+				break
+			}
+			if !got.Equal(mustTime(t, want)) {
+				t.Errorf("%s from %s: occurrence %d = %s, want %s", tt.rule, tt.start, i, got.Format(time.RFC3339), want)
+				break
+			}
+			got, ok = rule.Next(start, got)
+		}
+	}
+}
+
+func TestNextAfter(t *testing.T) {
+	tests := []struct {
+		rule  string
+		start string
+		after string
+		want  string
+	}{
+		{rule: "FREQ=DAILY", start: "2026-01-01T09:00:00Z", after: "2026-06-15T12:00:00Z", want: "2026-06-16T09:00:00Z"},
+		{rule: "FREQ=DAILY", start: "2026-01-01T09:00:00Z", after: "2026-06-15T08:59:59Z", want: "2026-06-15T09:00:00Z"},
+		{rule: "FREQ=DAILY", start: "2026-01-01T09:00:00Z", after: "2025-01-01T00:00:00Z", want: "2026-01-01T09:00:00Z"},
+		{rule: "FREQ=WEEKLY;INTERVAL=3", start: "2026-01-05T09:00:00Z", after: "2026-03-01T00:00:00Z", want: "2026-03-09T09:00:00Z"},
+		{rule: "FREQ=MONTHLY", start: "2026-01-31T09:00:00Z", after: "2030-02-28T09:00:00Z", want: "2030-03-31T09:00:00Z"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=31", start: "2026-01-01T00:00:00Z", after: "2026-03-31T00:00:00Z", want: "2026-05-31T00:00:00Z"},
+		{rule: "FREQ=YEARLY;INTERVAL=2", start: "2026-07-01T00:00:00Z", after: "2031-01-01T00:00:00Z", want: "2032-07-01T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.rule, err)
+		}
+
+		got, ok := rule.Next(mustTime(t, tt.start), mustTime(t, tt.after))
+		if !ok || !got.Equal(mustTime(t, tt.want)) {
+			t.Errorf("%s from %s: Next after %s = %s, %t, want %s", tt.rule, tt.start, tt.after, got.Format(time.RFC3339), ok, tt.want)
+		}
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	// Every twelfth month from April is always April, which has no 31st.
+	rule, err := Parse("FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=31")
+	if err != nil {
+		t.Fatalf("Parse error = %v", err)
+	}
+
+	if got, ok := rule.First(mustTime(t, "2026-04-01T00:00:00Z")); ok {
+		t.Errorf("First = %s, want no occurrence", got)
+	}
+}
+
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatalf("time.Parse(%q) error = %v", s, err)
+	}
+	return parsed
+}
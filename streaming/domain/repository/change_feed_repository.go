@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"go-api-streaming/domain/entity"
+)
+
+type ChangeFeedRepository interface {
+	// LockPending returns unprocessed changes in order and locks them for the
+	// surrounding transaction, skipping rows locked by other listeners.
+	LockPending(ctx context.Context, limit int) ([]*entity.TransactionChange, error)
+	MarkProcessed(ctx context.Context, id int64) error
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"go-api-streaming/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+type EventLogRepository interface {
+	// Append stores the entry and sets its Sequence. It must run inside a
+	// transaction so each user's sequences become visible in the order they
+	// were given. It locks the user's events until commit, so a transaction
+	// appending events of several users must append them in a fixed order.
+	Append(ctx context.Context, entry *entity.EventLogEntry) error
+	// ListSince returns up to limit entries with a sequence greater than
+	// since, oldest first. A nil userID returns entries of all users; as
+	// sequences are only committed in order per user, such a page may miss an
+	// event of another user that was still being committed.
+	ListSince(ctx context.Context, userID uuid.UUID, since int64, limit int) ([]*entity.EventLogEntry, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-api-streaming/domain/entity"
+	"time"
+)
+
+// ErrFXRateNotFound is returned when no rate for a currency pair is in
+// effect.
+var ErrFXRateNotFound = errors.New("exchange rate not found")
+
+type FXRateRepository interface {
+	// Save stores rates, replacing any rate of the same pair with the same
+	// effective time.
+	Save(ctx context.Context, rates []*entity.FXRate) error
+	// GetEffective returns the latest rate of the pair that took effect at or
+	// before at.
+	GetEffective(ctx context.Context, base, quote string, at time.Time) (*entity.FXRate, error)
+	// List returns rates newest first. Empty currencies match every pair.
+	List(ctx context.Context, base, quote string, limit int) ([]*entity.FXRate, error)
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"go-api-streaming/domain/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IdempotencyKeyRepository interface {
+	// Claim stores key unless the user already holds an unexpired key with
+	// the same value, reporting whether it did. While the surrounding
+	// transaction is open, concurrent claims of the same key wait for it.
+	Claim(ctx context.Context, key *entity.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, userID uuid.UUID, key string) (*entity.IdempotencyKey, error)
+	SaveResponse(ctx context.Context, userID uuid.UUID, key string, response json.RawMessage) error
+	// DeleteExpired removes up to limit keys that expired before now and
+	// returns how many it removed.
+	DeleteExpired(ctx context.Context, now time.Time, limit int) (int, error)
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/money"
+
+	"github.com/google/uuid"
+)
+
+type LedgerRepository interface {
+	// LockWallet returns the user's wallet account in currency, creating it
+	// if needed, and locks it for the surrounding transaction so balance
+	// checks and postings for the same wallet run one at a time.
+	LockWallet(ctx context.Context, userID uuid.UUID, currency string) (*entity.LedgerAccount, error)
+	// GetSystemAccount returns the named system account in currency,
+	// creating it if needed.
+	GetSystemAccount(ctx context.Context, name, currency string) (*entity.LedgerAccount, error)
+	// Balance returns the account's credits minus its debits.
+	Balance(ctx context.Context, accountID uuid.UUID) (money.Amount, error)
+	// ReservedAmount returns the total of the user's pending and processing
+	// withdrawals and purchases in currency.
+	ReservedAmount(ctx context.Context, userID uuid.UUID, currency string) (money.Amount, error)
+	HasEntries(ctx context.Context, transactionID uuid.UUID) (bool, error)
+	ListEntries(ctx context.Context, transactionID uuid.UUID) ([]*entity.LedgerEntry, error)
+	CreateEntries(ctx context.Context, entries []*entity.LedgerEntry) error
+	// ListWallets returns the user's balance in every currency they hold.
+	ListWallets(ctx context.Context, userID uuid.UUID) ([]*entity.Wallet, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"go-api-streaming/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+type OutboxRepository interface {
+	Create(ctx context.Context, event *entity.OutboxEvent) error
+	// LockPending returns unpublished events in creation order and locks them
+	// for the surrounding transaction, skipping rows locked by other relays.
+	LockPending(ctx context.Context, limit int) ([]*entity.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string) error
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-api-streaming/domain/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrScheduleNotFound is returned when a schedule does not exist or belongs to
+// another user.
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+type ScheduleRepository interface {
+	Create(ctx context.Context, schedule *entity.Schedule) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entity.Schedule, error)
+	// LockByID is GetByID that also locks the row for the surrounding
+	// transaction.
+	LockByID(ctx context.Context, id uuid.UUID) (*entity.Schedule, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*entity.Schedule, error)
+	// LockDue returns active schedules whose next run is due at now, locked
+	// for the surrounding transaction and skipping rows locked by other
+	// schedulers.
+	LockDue(ctx context.Context, now time.Time, limit int) ([]*entity.Schedule, error)
+	Update(ctx context.Context, schedule *entity.Schedule) error
+}
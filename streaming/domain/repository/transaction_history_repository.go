@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"go-api-streaming/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+// TransactionHistoryRepository stores the status history of transactions.
+// Rows are never updated or deleted.
+type TransactionHistoryRepository interface {
+	Append(ctx context.Context, change *entity.TransactionStatusChange) error
+	// ListByTransactionID returns a transaction's history, oldest first.
+	ListByTransactionID(ctx context.Context, transactionID uuid.UUID) ([]*entity.TransactionStatusChange, error)
+}
@@ -2,17 +2,45 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"go-api-streaming/domain/entity"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrTransactionNotFound is returned when no transaction has the given ID.
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// TransactionFilter narrows a transaction query. Zero fields match
+// everything.
+type TransactionFilter struct {
+	UserID uuid.UUID
+	Status string
+}
+
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction *entity.Transaction) error
 	GetByID(ctx context.Context, id uuid.UUID) (*entity.Transaction, error)
+	// LockByID is GetByID that also locks the row for the surrounding
+	// transaction, so concurrent status changes apply one at a time.
+	LockByID(ctx context.Context, id uuid.UUID) (*entity.Transaction, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*entity.Transaction, error)
+	// GetByParentID returns the refunds and reversals of a transaction,
+	// oldest first.
+	GetByParentID(ctx context.Context, parentID uuid.UUID) ([]*entity.Transaction, error)
 	Update(ctx context.Context, transaction *entity.Transaction) error
 	GetAll(ctx context.Context, limit, offset int) ([]*entity.Transaction, error)
 	GetByStatus(ctx context.Context, status string, limit, offset int) ([]*entity.Transaction, error)
 	UserExists(ctx context.Context, userID uuid.UUID) (bool, error)
+	// LockExpiredPending locks up to limit pending transactions of a type
+	// created before the given time, oldest first. Rows locked elsewhere are
+	// skipped, so several callers can work through them at once.
+	LockExpiredPending(ctx context.Context, transactionType string, createdBefore time.Time, limit int) ([]*entity.Transaction, error)
+	// Stream calls fn for every transaction matching filter, newest first,
+	// reading rows from a cursor so memory use does not grow with the result.
+	Stream(ctx context.Context, filter TransactionFilter, fn func(*entity.Transaction) error) error
+	// Summarize counts and sums the transactions matching filter per type and
+	// currency. ConvertedAmount is left zero.
+	Summarize(ctx context.Context, filter TransactionFilter) ([]*entity.TransactionTotal, error)
 }
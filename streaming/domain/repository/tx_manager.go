@@ -0,0 +1,9 @@
+package repository
+
+import "context"
+
+// TxManager runs a function inside a database transaction. Repository calls
+// made with the context passed to fn take part in that transaction.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"go-api-streaming/domain/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserProjectionRepository interface {
+	// Upsert stores the user unless a newer event has already been applied.
+	Upsert(ctx context.Context, user *entity.UserProjection) error
+	// MarkDeleted flags the user as deleted unless a newer event has already
+	// been applied.
+	MarkDeleted(ctx context.Context, id uuid.UUID, eventAt time.Time) error
+	// GetByID returns nil without an error when the user is unknown.
+	GetByID(ctx context.Context, id uuid.UUID) (*entity.UserProjection, error)
+}
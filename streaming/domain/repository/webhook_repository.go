@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-api-streaming/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+// ErrWebhookNotFound is returned when a webhook subscription or delivery does
+// not exist or belongs to another user.
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (*entity.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]*entity.WebhookSubscription, error)
+	// ListActiveSubscriptions returns userID's active subscriptions to
+	// eventType.
+	ListActiveSubscriptions(ctx context.Context, userID uuid.UUID, eventType string) ([]*entity.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	// RecordSubscriptionSuccess resets the subscription's failure count.
+	RecordSubscriptionSuccess(ctx context.Context, id uuid.UUID) error
+	// RecordSubscriptionFailure counts a failed attempt and disables the
+	// subscription once disableAfter consecutive attempts have failed. It
+	// reports whether the subscription was disabled by this call.
+	RecordSubscriptionFailure(ctx context.Context, id uuid.UUID, disableAfter int) (bool, error)
+
+	// CreateDelivery queues a delivery, ignoring one that already exists for
+	// the same subscription and event.
+	CreateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error
+	// LockDueDeliveries returns pending deliveries of active subscriptions
+	// whose next attempt is due, locked for the surrounding transaction and
+	// skipping rows locked by other dispatchers.
+	LockDueDeliveries(ctx context.Context, limit int) ([]*entity.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id, subscriptionID uuid.UUID) (*entity.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]*entity.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error
+	CreateAttempt(ctx context.Context, attempt *entity.WebhookDeliveryAttempt) error
+	ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*entity.WebhookDeliveryAttempt, error)
+}
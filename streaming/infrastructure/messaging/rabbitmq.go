@@ -2,40 +2,386 @@ package messaging
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go-api-streaming/infrastructure/config"
+	"hash/fnv"
 	"log"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/streadway/amqp"
 )
 
+var (
+	// ErrNotConnected is returned when the broker stays unreachable for longer
+	// than the configured publish timeout.
+	ErrNotConnected = errors.New("rabbitmq: not connected")
+	// ErrClientClosed is returned once Close has been called on the client.
+	ErrClientClosed = errors.New("rabbitmq: client closed")
+	// ErrPublishNacked is returned when the broker refuses to take
+	// responsibility for a published message.
+	ErrPublishNacked = errors.New("rabbitmq: message nacked by broker")
+	// ErrConfirmTimeout is returned when the broker does not confirm a
+	// message within the publish timeout. The message may or may not have
+	// been stored.
+	ErrConfirmTimeout = errors.New("rabbitmq: timed out waiting for publisher confirm")
+)
+
+// UnroutableError is returned when a mandatory message could not be routed to
+// any queue and was handed back by the broker.
+type UnroutableError struct {
+	Exchange   string
+	RoutingKey string
+	ReplyCode  uint16
+	ReplyText  string
+}
+
+func (e *UnroutableError) Error() string {
+	return fmt.Sprintf("rabbitmq: message to exchange %q with routing key %q returned: %d %s",
+		e.Exchange, e.RoutingKey, e.ReplyCode, e.ReplyText)
+}
+
+// session is a channel in confirm mode together with its confirm and return
+// notifications. A new session is created on every reconnect.
+type session struct {
+	channel  *amqp.Channel
+	confirms chan amqp.Confirmation
+	returns  chan amqp.Return
+	nextTag  uint64
+}
+
+// ConsumerOptions tunes how a queue is consumed. Zero values fall back to the
+// per-queue or default settings from the configuration.
+type ConsumerOptions struct {
+	// PrefetchCount limits unacknowledged deliveries held by the consumer.
+	PrefetchCount int
+	// Workers is the number of goroutines handling deliveries concurrently.
+	Workers int
+	// OrderingKey, when set, routes every message with the same key to the
+	// same worker so they are handled in arrival order.
+	OrderingKey func(body []byte) string
+	// Transient skips the retry and dead-letter topology. Failed messages are
+	// logged and dropped, which suits best-effort live fan-out queues.
+	Transient bool
+}
+
+type consumer struct {
+	queueName string
+	handler   func([]byte) error
+	opts      ConsumerOptions
+}
+
+// topologyStep declares part of the broker topology (a queue, exchange or
+// binding) on a channel. Steps are replayed in order after a reconnect.
+type topologyStep func(channel *amqp.Channel) error
+
+// RabbitMQClient keeps a connection and channel to the broker alive. When the
+// connection drops it reconnects with exponential backoff, re-declares every
+// queue, exchange and binding it knows about and re-registers every consumer.
 type RabbitMQClient struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
+	cfg *config.RabbitMQConfig
+
+	mu        sync.RWMutex
+	pubMu     sync.Mutex // serialises confirmed publishes
+	conn      *amqp.Connection
+	session   *session
+	ready     chan struct{} // closed while a usable channel is available
+	topology  []topologyStep
+	consumers []consumer
+	closed    bool
+	done      chan struct{}
 }
 
 func NewRabbitMQClient(cfg *config.RabbitMQConfig) (*RabbitMQClient, error) {
-	conn, err := amqp.Dial(cfg.URL)
+	r := &RabbitMQClient{
+		cfg:   cfg,
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	connClosed, chanClosed, err := r.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("✓ RabbitMQ connection established")
+
+	go r.watch(connClosed, chanClosed)
+
+	return r, nil
+}
+
+// connect dials the broker, opens a channel and replays the known topology and
+// consumers on it. The returned channels fire when the connection or channel
+// is closed.
+func (r *RabbitMQClient) connect() (chan *amqp.Error, chan *amqp.Error, error) {
+	conn, err := amqp.Dial(r.cfg.URL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		return nil, nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
 
 	channel, err := conn.Channel()
 	if err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("failed to open channel: %w", err)
+		return nil, nil, fmt.Errorf("failed to open channel: %w", err)
 	}
 
-	fmt.Println("✓ RabbitMQ connection established")
+	if err := channel.Confirm(false); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	sess := &session{
+		channel:  channel,
+		confirms: channel.NotifyPublish(make(chan amqp.Confirmation, 64)),
+		returns:  channel.NotifyReturn(make(chan amqp.Return, 64)),
+		nextTag:  1,
+	}
+
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	chanClosed := channel.NotifyClose(make(chan *amqp.Error, 1))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, step := range r.topology {
+		if err := step(channel); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+
+	for _, c := range r.consumers {
+		if err := r.startConsumer(channel, c); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
 
-	return &RabbitMQClient{
-		conn:    conn,
-		channel: channel,
-	}, nil
+	r.conn = conn
+	r.session = sess
+	close(r.ready)
+
+	return connClosed, chanClosed, nil
+}
+
+// watch waits for the connection or channel to close and reconnects until it
+// succeeds or the client is closed.
+func (r *RabbitMQClient) watch(connClosed, chanClosed chan *amqp.Error) {
+	for {
+		var reason *amqp.Error
+		select {
+		case <-r.done:
+			return
+		case reason = <-connClosed:
+		case reason = <-chanClosed:
+		}
+
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return
+		}
+		r.ready = make(chan struct{})
+		if r.conn != nil {
+			r.conn.Close()
+		}
+		r.conn = nil
+		r.session = nil
+		r.mu.Unlock()
+
+		log.Printf("RabbitMQ connection lost: %v, reconnecting", reason)
+
+		var ok bool
+		connClosed, chanClosed, ok = r.reconnect()
+		if !ok {
+			return
+		}
+
+		log.Printf("✓ RabbitMQ connection re-established")
+	}
+}
+
+// minReconnectDelay bounds the reconnect delays from below, so a zero or
+// negative setting cannot make the loop spin.
+const minReconnectDelay = 100 * time.Millisecond
+
+func (r *RabbitMQClient) reconnect() (chan *amqp.Error, chan *amqp.Error, bool) {
+	delay := r.cfg.ReconnectInitialDelay
+	if delay < minReconnectDelay {
+		delay = minReconnectDelay
+	}
+	maxDelay := r.cfg.ReconnectMaxDelay
+	if maxDelay < delay {
+		maxDelay = delay
+	}
+
+	for {
+		select {
+		case <-r.done:
+			return nil, nil, false
+		case <-time.After(delay):
+		}
+
+		connClosed, chanClosed, err := r.connect()
+		if err == nil {
+			return connClosed, chanClosed, true
+		}
+
+		log.Printf("RabbitMQ reconnect failed: %v, retrying in %s", err, delay)
+
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// awaitSession returns the current session, waiting up to the publish timeout
+// for a reconnect when the broker is unavailable.
+func (r *RabbitMQClient) awaitSession() (*session, error) {
+	r.mu.RLock()
+	ready, closed := r.ready, r.closed
+	r.mu.RUnlock()
+
+	if closed {
+		return nil, ErrClientClosed
+	}
+
+	timer := time.NewTimer(r.cfg.PublishTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+	case <-r.done:
+		return nil, ErrClientClosed
+	case <-timer.C:
+		return nil, ErrNotConnected
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.session == nil {
+		return nil, ErrNotConnected
+	}
+	return r.session, nil
+}
+
+// declare runs step on the current channel and remembers it for reconnects.
+func (r *RabbitMQClient) declare(step topologyStep) error {
+	sess, err := r.awaitSession()
+	if err != nil {
+		return err
+	}
+
+	if err := step(sess.channel); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.topology = append(r.topology, step)
+	r.mu.Unlock()
+
+	return nil
 }
 
 func (r *RabbitMQClient) DeclareQueue(queueName string) error {
-	_, err := r.channel.QueueDeclare(
+	err := r.declare(func(channel *amqp.Channel) error {
+		return declareQueue(channel, queueName)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+	return nil
+}
+
+// DeclareExchange declares a durable exchange of the given kind, e.g. "topic".
+func (r *RabbitMQClient) DeclareExchange(exchangeName, kind string) error {
+	return r.declareExchange(exchangeName, kind, nil)
+}
+
+// DeclareExchangeWithAlternate declares a durable exchange whose unroutable
+// messages go to a fanout alternate exchange with a queue of the same name,
+// instead of being returned to the publisher. This lets subscribers bind
+// selectively without stalling mandatory publishes.
+func (r *RabbitMQClient) DeclareExchangeWithAlternate(exchangeName, kind, alternateExchange string) error {
+	if err := r.declareExchange(alternateExchange, "fanout", nil); err != nil {
+		return err
+	}
+	if err := r.DeclareQueue(alternateExchange); err != nil {
+		return err
+	}
+	if err := r.BindQueue(alternateExchange, alternateExchange, ""); err != nil {
+		return err
+	}
+
+	return r.declareExchange(exchangeName, kind, amqp.Table{
+		"alternate-exchange": alternateExchange,
+	})
+}
+
+func (r *RabbitMQClient) declareExchange(exchangeName, kind string, args amqp.Table) error {
+	err := r.declare(func(channel *amqp.Channel) error {
+		return channel.ExchangeDeclare(
+			exchangeName,
+			kind,
+			true,  // durable
+			false, // auto-deleted
+			false, // internal
+			false, // no-wait
+			args,  // arguments
+		)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+	return nil
+}
+
+func (r *RabbitMQClient) BindQueue(queueName, exchangeName, routingKey string) error {
+	err := r.declare(func(channel *amqp.Channel) error {
+		return channel.QueueBind(
+			queueName,
+			routingKey,
+			exchangeName,
+			false, // no-wait
+			nil,   // arguments
+		)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to bind queue: %w", err)
+	}
+
+	log.Printf("✓ Queue %s bound to %s with routing key %s", queueName, exchangeName, routingKey)
+	return nil
+}
+
+// DeclareTransientQueue declares an exclusive, auto-deleted queue that lives
+// as long as the connection. It is declared again under the same name after a
+// reconnect, which makes it suitable for per-instance fan-out.
+func (r *RabbitMQClient) DeclareTransientQueue(queueName string) error {
+	err := r.declare(func(channel *amqp.Channel) error {
+		_, err := channel.QueueDeclare(
+			queueName,
+			false, // durable
+			true,  // delete when unused
+			true,  // exclusive
+			false, // no-wait
+			nil,   // arguments
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+	return nil
+}
+
+func declareQueue(channel *amqp.Channel, queueName string) error {
+	_, err := channel.QueueDeclare(
 		queueName,
 		true,  // durable
 		false, // delete when unused
@@ -43,39 +389,190 @@ func (r *RabbitMQClient) DeclareQueue(queueName string) error {
 		false, // no-wait
 		nil,   // arguments
 	)
-	if err != nil {
-		return fmt.Errorf("failed to declare queue: %w", err)
-	}
-	return nil
+	return err
+}
+
+// PublishOptions sets AMQP properties of a published message. Zero values are
+// filled in by Publish.
+type PublishOptions struct {
+	MessageID     string
+	Type          string
+	CorrelationID string
+	ContentType   string
+	Timestamp     time.Time
 }
 
+// PublishMessage publishes a message straight to queueName through the
+// default exchange. See Publish.
 func (r *RabbitMQClient) PublishMessage(queueName string, message interface{}) error {
+	return r.Publish("", queueName, message, PublishOptions{})
+}
+
+// Publish publishes a persistent, mandatory message to an exchange and
+// returns once the broker has confirmed it. Unroutable messages are reported
+// as *UnroutableError and refused messages as ErrPublishNacked. Every message
+// carries a message_id, type and timestamp; missing ones default to a new
+// UUID, the routing key and the current time.
+func (r *RabbitMQClient) Publish(exchangeName, routingKey string, message interface{}, opts PublishOptions) error {
 	body, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	err = r.channel.Publish(
-		"",        // exchange
-		queueName, // routing key
-		false,     // mandatory
-		false,     // immediate
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		},
-	)
+	if opts.MessageID == "" {
+		opts.MessageID = uuid.New().String()
+	}
+	if opts.Type == "" {
+		opts.Type = routingKey
+	}
+	if opts.ContentType == "" {
+		opts.ContentType = "application/json"
+	}
+	if opts.Timestamp.IsZero() {
+		opts.Timestamp = time.Now()
+	}
+
+	sess, err := r.awaitSession()
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
 
-	log.Printf("✓ Message published to queue: %s", queueName)
+	if err := r.publishConfirmed(sess, exchangeName, routingKey, amqp.Publishing{
+		ContentType:   opts.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     opts.MessageID,
+		Type:          opts.Type,
+		CorrelationId: opts.CorrelationID,
+		Timestamp:     opts.Timestamp,
+		Body:          body,
+	}); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	if exchangeName == "" {
+		log.Printf("✓ Message published to queue: %s", routingKey)
+	} else {
+		log.Printf("✓ Message published to exchange %s with routing key %s", exchangeName, routingKey)
+	}
 	return nil
 }
 
+func (r *RabbitMQClient) publishConfirmed(sess *session, exchange, routingKey string, msg amqp.Publishing) error {
+	r.pubMu.Lock()
+	defer r.pubMu.Unlock()
+
+	// Drop returns left over from publishes that timed out earlier.
+	for drained := false; !drained; {
+		select {
+		case _, ok := <-sess.returns:
+			drained = !ok
+		default:
+			drained = true
+		}
+	}
+
+	if err := sess.channel.Publish(
+		exchange,
+		routingKey,
+		true,  // mandatory
+		false, // immediate
+		msg,
+	); err != nil {
+		return err
+	}
+
+	tag := sess.nextTag
+	sess.nextTag++
+
+	timer := time.NewTimer(r.cfg.PublishTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case confirm, ok := <-sess.confirms:
+			if !ok {
+				return ErrNotConnected
+			}
+			if confirm.DeliveryTag < tag {
+				continue // confirm for a publish that already timed out
+			}
+			if !confirm.Ack {
+				return ErrPublishNacked
+			}
+			// The broker sends basic.return before the ack of an
+			// unroutable message, so it is already buffered here.
+			select {
+			case ret, ok := <-sess.returns:
+				if !ok {
+					return nil
+				}
+				return &UnroutableError{
+					Exchange:   ret.Exchange,
+					RoutingKey: ret.RoutingKey,
+					ReplyCode:  ret.ReplyCode,
+					ReplyText:  ret.ReplyText,
+				}
+			default:
+				return nil
+			}
+		case <-timer.C:
+			return ErrConfirmTimeout
+		}
+	}
+}
+
+// Consume registers handler for queueName with the configured prefetch and
+// worker pool. See ConsumeWithOptions.
 func (r *RabbitMQClient) Consume(queueName string, handler func([]byte) error) error {
-	msgs, err := r.channel.Consume(
-		queueName,
+	return r.ConsumeWithOptions(queueName, handler, ConsumerOptions{})
+}
+
+// ConsumeWithOptions registers handler for queueName. Deliveries are handled
+// by a pool of workers, each acking or nacking the messages it handled. A
+// handler error sends the message through exponential-backoff delay queues up
+// to the configured retry limit, after which it is moved to the queue's
+// dead-letter queue.
+func (r *RabbitMQClient) ConsumeWithOptions(queueName string, handler func([]byte) error, opts ConsumerOptions) error {
+	if opts.PrefetchCount <= 0 {
+		opts.PrefetchCount = r.cfg.PrefetchFor(queueName)
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = r.cfg.WorkersFor(queueName)
+	}
+
+	if !opts.Transient {
+		if err := r.declareRetryTopology(queueName); err != nil {
+			return fmt.Errorf("failed to register consumer: %w", err)
+		}
+	}
+
+	sess, err := r.awaitSession()
+	if err != nil {
+		return fmt.Errorf("failed to register consumer: %w", err)
+	}
+
+	c := consumer{queueName: queueName, handler: handler, opts: opts}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.startConsumer(sess.channel, c); err != nil {
+		return err
+	}
+	r.consumers = append(r.consumers, c)
+
+	return nil
+}
+
+func (r *RabbitMQClient) startConsumer(channel *amqp.Channel, c consumer) error {
+	// With global=false the prefetch applies to consumers started afterwards
+	// on this channel, so every consumer gets its own limit.
+	if err := channel.Qos(c.opts.PrefetchCount, 0, false); err != nil {
+		return fmt.Errorf("failed to set QoS: %w", err)
+	}
+
+	msgs, err := channel.Consume(
+		c.queueName,
 		"",    // consumer
 		false, // auto-ack
 		false, // exclusive
@@ -87,24 +584,88 @@ func (r *RabbitMQClient) Consume(queueName string, handler func([]byte) error) e
 		return fmt.Errorf("failed to register consumer: %w", err)
 	}
 
-	go func() {
-		for msg := range msgs {
-			if err := handler(msg.Body); err != nil {
-				log.Printf("Error handling message: %v", err)
-				msg.Nack(false, true) // requeue on error
-			} else {
-				msg.Ack(false)
-			}
-		}
-	}()
+	go r.dispatch(c, msgs)
 
-	log.Printf("✓ Consumer registered for queue: %s", queueName)
+	log.Printf("✓ Consumer registered for queue: %s (workers: %d, prefetch: %d)", c.queueName, c.opts.Workers, c.opts.PrefetchCount)
 	return nil
 }
 
+// dispatch hands deliveries to the worker pool until the channel closes.
+// Without an ordering key all workers share one queue; with one, each key is
+// pinned to a worker.
+func (r *RabbitMQClient) dispatch(c consumer, msgs <-chan amqp.Delivery) {
+	var wg sync.WaitGroup
+	work := func(queue <-chan amqp.Delivery) {
+		defer wg.Done()
+		for msg := range queue {
+			r.handleDelivery(c, msg)
+		}
+	}
+
+	var queues []chan amqp.Delivery
+	if c.opts.OrderingKey == nil {
+		shared := make(chan amqp.Delivery)
+		queues = append(queues, shared)
+		for i := 0; i < c.opts.Workers; i++ {
+			wg.Add(1)
+			go work(shared)
+		}
+	} else {
+		for i := 0; i < c.opts.Workers; i++ {
+			queue := make(chan amqp.Delivery)
+			queues = append(queues, queue)
+			wg.Add(1)
+			go work(queue)
+		}
+	}
+
+	for msg := range msgs {
+		queue := queues[0]
+		if c.opts.OrderingKey != nil {
+			queue = queues[workerIndex(c.opts.OrderingKey(msg.Body), len(queues))]
+		}
+		queue <- msg
+	}
+
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+
+	log.Printf("Consumer for queue %s stopped, waiting for reconnect", c.queueName)
+}
+
+func (r *RabbitMQClient) handleDelivery(c consumer, msg amqp.Delivery) {
+	if err := c.handler(msg.Body); err != nil {
+		if c.opts.Transient {
+			log.Printf("Dropping message from %s: %v", c.queueName, err)
+			msg.Ack(false)
+			return
+		}
+		r.handleFailure(c.queueName, msg, err)
+		return
+	}
+	msg.Ack(false)
+}
+
+func workerIndex(key string, workers int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(workers))
+}
+
 func (r *RabbitMQClient) Close() error {
-	if r.channel != nil {
-		r.channel.Close()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	close(r.done)
+
+	if r.session != nil {
+		r.session.channel.Close()
 	}
 	if r.conn != nil {
 		return r.conn.Close()
@@ -0,0 +1,335 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	headerRetryCount    = "x-retry-count"
+	headerOriginalQueue = "x-original-queue"
+	headerLastError     = "x-last-error"
+	headerDeadLetterAt  = "x-dead-lettered-at"
+
+	maxErrorHeaderLength = 1024
+)
+
+// ErrUnknownQueue is returned by the dead-letter operations for queues that
+// have no consumer registered through Consume.
+var ErrUnknownQueue = errors.New("rabbitmq: queue has no registered consumer")
+
+// PermanentError marks a handler error that retrying cannot fix. Messages
+// failing with it are dead-lettered straight away.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err so the message is dead-lettered without retries.
+func Permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// DeadLetter is a message parked in a dead-letter queue after its retries ran
+// out.
+type DeadLetter struct {
+	MessageID      string          `json:"message_id,omitempty"`
+	Type           string          `json:"type,omitempty"`
+	RetryCount     int             `json:"retry_count"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeadLetteredAt string          `json:"dead_lettered_at,omitempty"`
+	Body           json.RawMessage `json:"body"`
+}
+
+func deadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+func retryQueueName(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%s", queueName, delay)
+}
+
+// retryDelay returns the backoff before the given attempt (starting at 1).
+func (r *RabbitMQClient) retryDelay(attempt int) time.Duration {
+	delay := r.cfg.RetryInitialDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= r.cfg.RetryMaxDelay {
+			return r.cfg.RetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// declareRetryTopology declares the dead-letter exchange and queue and one
+// delay queue per retry attempt for queueName. A delay queue holds a message
+// for its TTL and then dead-letters it back into queueName.
+func (r *RabbitMQClient) declareRetryTopology(queueName string) error {
+	dlx := r.cfg.DeadLetterExchange
+	dlq := deadLetterQueueName(queueName)
+
+	if err := r.DeclareExchange(dlx, "direct"); err != nil {
+		return err
+	}
+	if err := r.DeclareQueue(dlq); err != nil {
+		return err
+	}
+	if err := r.BindQueue(dlq, dlx, queueName); err != nil {
+		return err
+	}
+
+	declared := make(map[time.Duration]bool)
+	for attempt := 1; attempt <= r.cfg.MaxRetries; attempt++ {
+		delay := r.retryDelay(attempt)
+		if declared[delay] {
+			continue
+		}
+		declared[delay] = true
+
+		retryQueue := retryQueueName(queueName, delay)
+		err := r.declare(func(channel *amqp.Channel) error {
+			_, err := channel.QueueDeclare(
+				retryQueue,
+				true,  // durable
+				false, // delete when unused
+				false, // exclusive
+				false, // no-wait
+				amqp.Table{
+					"x-message-ttl":             int64(delay / time.Millisecond),
+					"x-dead-letter-exchange":    "",
+					"x-dead-letter-routing-key": queueName,
+				},
+			)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to declare retry queue: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// handleFailure schedules a failed delivery for another attempt through the
+// matching delay queue, or moves it to the dead-letter queue once retries are
+// exhausted or the error is permanent. The original delivery is acked only
+// after the copy has been confirmed by the broker.
+func (r *RabbitMQClient) handleFailure(queueName string, msg amqp.Delivery, handlerErr error) {
+	exchange, routingKey, headers := r.failureRoute(queueName, msg.Headers, handlerErr, time.Now())
+
+	sess, err := r.awaitSession()
+	if err == nil {
+		err = r.publishConfirmed(sess, exchange, routingKey, republishing(msg, headers))
+	}
+	if err != nil {
+		log.Printf("Failed to reschedule message from %s, requeueing: %v", queueName, err)
+		msg.Nack(false, true)
+		return
+	}
+
+	msg.Ack(false)
+}
+
+// failureRoute returns where a delivery of queueName that failed with
+// handlerErr is published next, and the headers it carries there.
+func (r *RabbitMQClient) failureRoute(queueName string, received amqp.Table, handlerErr error, now time.Time) (exchange, routingKey string, headers amqp.Table) {
+	retries := retryCount(received)
+
+	var permanent *PermanentError
+	if !errors.As(handlerErr, &permanent) && retries < r.cfg.MaxRetries {
+		retries++
+		exchange, routingKey = "", retryQueueName(queueName, r.retryDelay(retries))
+		log.Printf("Error handling message from %s (attempt %d of %d): %v", queueName, retries, r.cfg.MaxRetries, handlerErr)
+	} else {
+		exchange, routingKey = r.cfg.DeadLetterExchange, queueName
+		log.Printf("Dead-lettering message from %s after %d retries: %v", queueName, retries, handlerErr)
+	}
+
+	headers = copyHeaders(received)
+	headers[headerRetryCount] = int32(retries)
+	headers[headerOriginalQueue] = queueName
+	headers[headerLastError] = truncate(handlerErr.Error(), maxErrorHeaderLength)
+	if exchange != "" {
+		headers[headerDeadLetterAt] = now.UTC().Format(time.RFC3339)
+	}
+
+	return exchange, routingKey, headers
+}
+
+// ListDeadLetters returns up to limit messages from the dead-letter queue of
+// queueName without removing them.
+func (r *RabbitMQClient) ListDeadLetters(queueName string, limit int) ([]DeadLetter, error) {
+	if !r.hasConsumer(queueName) {
+		return nil, ErrUnknownQueue
+	}
+
+	channel, err := r.openChannel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	// Closing the channel requeues every message fetched without an ack.
+	defer channel.Close()
+
+	deadLetters := []DeadLetter{}
+	for len(deadLetters) < limit {
+		msg, ok, err := channel.Get(deadLetterQueueName(queueName), false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list dead letters: %w", err)
+		}
+		if !ok {
+			break
+		}
+		deadLetters = append(deadLetters, toDeadLetter(msg))
+	}
+
+	return deadLetters, nil
+}
+
+// RedriveDeadLetters moves up to limit messages from the dead-letter queue of
+// queueName back onto queueName with a fresh retry budget. It returns the
+// number of messages moved.
+func (r *RabbitMQClient) RedriveDeadLetters(queueName string, limit int) (int, error) {
+	if !r.hasConsumer(queueName) {
+		return 0, ErrUnknownQueue
+	}
+
+	channel, err := r.openChannel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to redrive dead letters: %w", err)
+	}
+	defer channel.Close()
+
+	moved := 0
+	for moved < limit {
+		msg, ok, err := channel.Get(deadLetterQueueName(queueName), false)
+		if err != nil {
+			return moved, fmt.Errorf("failed to redrive dead letters: %w", err)
+		}
+		if !ok {
+			break
+		}
+
+		headers := copyHeaders(msg.Headers)
+		delete(headers, headerRetryCount)
+		delete(headers, headerDeadLetterAt)
+
+		sess, err := r.awaitSession()
+		if err == nil {
+			err = r.publishConfirmed(sess, "", queueName, republishing(msg, headers))
+		}
+		if err != nil {
+			msg.Nack(false, true)
+			return moved, fmt.Errorf("failed to redrive dead letters: %w", err)
+		}
+
+		msg.Ack(false)
+		moved++
+	}
+
+	log.Printf("✓ Redrove %d dead letters to queue: %s", moved, queueName)
+	return moved, nil
+}
+
+func (r *RabbitMQClient) hasConsumer(queueName string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, c := range r.consumers {
+		if c.queueName == queueName && !c.opts.Transient {
+			return true
+		}
+	}
+	return false
+}
+
+// openChannel opens a short-lived channel on the current connection.
+func (r *RabbitMQClient) openChannel() (*amqp.Channel, error) {
+	if _, err := r.awaitSession(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.conn == nil {
+		return nil, ErrNotConnected
+	}
+	return r.conn.Channel()
+}
+
+func republishing(msg amqp.Delivery, headers amqp.Table) amqp.Publishing {
+	return amqp.Publishing{
+		Headers:       headers,
+		ContentType:   msg.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: msg.CorrelationId,
+		MessageId:     msg.MessageId,
+		Timestamp:     msg.Timestamp,
+		Type:          msg.Type,
+		AppId:         msg.AppId,
+		Body:          msg.Body,
+	}
+}
+
+func toDeadLetter(msg amqp.Delivery) DeadLetter {
+	body := json.RawMessage(msg.Body)
+	if !json.Valid(msg.Body) {
+		body, _ = json.Marshal(string(msg.Body))
+	}
+
+	lastError, _ := msg.Headers[headerLastError].(string)
+	deadLetteredAt, _ := msg.Headers[headerDeadLetterAt].(string)
+
+	return DeadLetter{
+		MessageID:      msg.MessageId,
+		Type:           msg.Type,
+		RetryCount:     retryCount(msg.Headers),
+		LastError:      lastError,
+		DeadLetteredAt: deadLetteredAt,
+		Body:           body,
+	}
+}
+
+func retryCount(headers amqp.Table) int {
+	switch v := headers[headerRetryCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int16:
+		return int(v)
+	case int8:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+func copyHeaders(headers amqp.Table) amqp.Table {
+	copied := make(amqp.Table, len(headers)+4)
+	for k, v := range headers {
+		copied[k] = v
+	}
+	return copied
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
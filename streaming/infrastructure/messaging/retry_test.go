@@ -0,0 +1,135 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"go-api-streaming/infrastructure/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+func TestRetryDelay(t *testing.T) {
+	client := &RabbitMQClient{cfg: &config.RabbitMQConfig{
+		RetryInitialDelay: time.Second,
+		RetryMaxDelay:     30 * time.Second,
+	}}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i, delay := range want {
+		if got := client.retryDelay(i + 1); got != delay {
+			t.Errorf("retryDelay(%d) = %s, want %s", i+1, got, delay)
+		}
+	}
+}
+
+func TestFailureRoute(t *testing.T) {
+	client := &RabbitMQClient{cfg: &config.RabbitMQConfig{
+		MaxRetries:         3,
+		RetryInitialDelay:  time.Second,
+		RetryMaxDelay:      time.Minute,
+		DeadLetterExchange: "dead_letters",
+	}}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	failure := errors.New("database unavailable")
+
+	tests := []struct {
+		name           string
+		retries        interface{}
+		err            error
+		wantExchange   string
+		wantRoutingKey string
+		wantRetries    int32
+	}{
+		{name: "first failure", retries: nil, err: failure, wantRoutingKey: "orders.retry.1s", wantRetries: 1},
+		{name: "second failure", retries: int32(1), err: failure, wantRoutingKey: "orders.retry.2s", wantRetries: 2},
+		{name: "last retry", retries: int64(2), err: failure, wantRoutingKey: "orders.retry.4s", wantRetries: 3},
+		{name: "retries exhausted", retries: int32(3), err: failure, wantExchange: "dead_letters", wantRoutingKey: "orders", wantRetries: 3},
+		{name: "permanent", retries: nil, err: Permanent(failure), wantExchange: "dead_letters", wantRoutingKey: "orders", wantRetries: 0},
+		{name: "wrapped permanent", retries: int32(1), err: fmt.Errorf("handle: %w", Permanent(failure)), wantExchange: "dead_letters", wantRoutingKey: "orders", wantRetries: 1},
+	}
+
+	for _, tt := range tests {
+		received := amqp.Table{"x-tenant": "acme"}
+		if tt.retries != nil {
+			received[headerRetryCount] = tt.retries
+		}
+
+		exchange, routingKey, headers := client.failureRoute("orders", received, tt.err, now)
+		if exchange != tt.wantExchange || routingKey != tt.wantRoutingKey {
+			t.Errorf("%s: routed to %q/%q, want %q/%q", tt.name, exchange, routingKey, tt.wantExchange, tt.wantRoutingKey)
+		}
+		if got := headers[headerRetryCount]; got != tt.wantRetries {
+			t.Errorf("%s: %s = %v, want %d", tt.name, headerRetryCount, got, tt.wantRetries)
+		}
+		if got := headers[headerOriginalQueue]; got != "orders" {
+			t.Errorf("%s: %s = %v, want orders", tt.name, headerOriginalQueue, got)
+		}
+		if got := headers[headerLastError]; got != tt.err.Error() {
+			t.Errorf("%s: %s = %v, want %q", tt.name, headerLastError, got, tt.err.Error())
+		}
+		if got := headers["x-tenant"]; got != "acme" {
+			t.Errorf("%s: x-tenant = %v, want the received header kept", tt.name, got)
+		}
+
+		deadLetterAt, dead := headers[headerDeadLetterAt]
+		if dead != (tt.wantExchange != "") || (dead && deadLetterAt != "2026-10-16T12:00:00Z") {
+			t.Errorf("%s: %s = %v, want it set only when dead-lettered", tt.name, headerDeadLetterAt, deadLetterAt)
+		}
+		if _, ok := received[headerRetryCount]; ok != (tt.retries != nil) {
+			t.Errorf("%s: the received headers were modified", tt.name)
+		}
+	}
+}
+
+func TestFailureRouteTruncatesError(t *testing.T) {
+	client := &RabbitMQClient{cfg: &config.RabbitMQConfig{MaxRetries: 1, RetryInitialDelay: time.Second, RetryMaxDelay: time.Second}}
+
+	_, _, headers := client.failureRoute("orders", nil, errors.New(strings.Repeat("x", 5000)), time.Now())
+	if got := headers[headerLastError].(string); len(got) != maxErrorHeaderLength {
+		t.Errorf("%s has %d bytes, want %d", headerLastError, len(got), maxErrorHeaderLength)
+	}
+}
+
+func TestToDeadLetter(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantBody string
+	}{
+		{name: "JSON body", body: `{"id":"1"}`, wantBody: `{"id":"1"}`},
+		{name: "text body", body: "not json", wantBody: `"not json"`},
+	}
+
+	for _, tt := range tests {
+		deadLetter := toDeadLetter(amqp.Delivery{
+			MessageId: "m-1",
+			Type:      "user.updated",
+			Headers: amqp.Table{
+				headerRetryCount:   int32(3),
+				headerLastError:    "boom",
+				headerDeadLetterAt: "2026-10-16T12:00:00Z",
+			},
+			Body: []byte(tt.body),
+		})
+
+		if string(deadLetter.Body) != tt.wantBody {
+			t.Errorf("%s: body = %s, want %s", tt.name, deadLetter.Body, tt.wantBody)
+		}
+		if deadLetter.MessageID != "m-1" || deadLetter.Type != "user.updated" || deadLetter.RetryCount != 3 ||
+			deadLetter.LastError != "boom" || deadLetter.DeadLetteredAt != "2026-10-16T12:00:00Z" {
+			t.Errorf("%s: dead letter = %+v", tt.name, deadLetter)
+		}
+	}
+}
+
+func TestQueueNames(t *testing.T) {
+	if got := deadLetterQueueName("orders"); got != "orders.dlq" {
+		t.Errorf("deadLetterQueueName = %s, want orders.dlq", got)
+	}
+	if got := retryQueueName("orders", 90*time.Second); got != "orders.retry.1m30s" {
+		t.Errorf("retryQueueName = %s, want orders.retry.1m30s", got)
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/repository"
+)
+
+type changeFeedRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewChangeFeedRepository(db *sql.DB) repository.ChangeFeedRepository {
+	return &changeFeedRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *changeFeedRepositoryImpl) LockPending(ctx context.Context, limit int) ([]*entity.TransactionChange, error) {
+	query := `
+		SELECT id, transaction_id, operation, previous_status, created_at, processed_at
+		FROM transaction_change_feed
+		WHERE processed_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending transaction changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*entity.TransactionChange
+	for rows.Next() {
+		change := &entity.TransactionChange{}
+		err := rows.Scan(
+			&change.ID,
+			&change.TransactionID,
+			&change.Operation,
+			&change.PreviousStatus,
+			&change.CreatedAt,
+			&change.ProcessedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (r *changeFeedRepositoryImpl) MarkProcessed(ctx context.Context, id int64) error {
+	query := `UPDATE transaction_change_feed SET processed_at = NOW() WHERE id = $1`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark transaction change processed: %w", err)
+	}
+
+	return nil
+}
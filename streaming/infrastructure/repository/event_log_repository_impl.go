@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// eventLogLockClass is the first key of the advisory locks serialising the
+// appends of one user's events; the second is a hash of the user ID. Holding
+// the lock until commit means none of a user's sequences is committed after a
+// higher one, so readers paging through a user's events by sequence cannot
+// skip one, while appends for different users run concurrently.
+const eventLogLockClass = 730_120_250
+
+type eventLogRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewEventLogRepository(db *sql.DB) repository.EventLogRepository {
+	return &eventLogRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *eventLogRepositoryImpl) Append(ctx context.Context, entry *entity.EventLogEntry) error {
+	exec := executor(ctx, r.db)
+
+	if _, err := exec.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, eventLogLockClass, entry.UserID.String()); err != nil {
+		return fmt.Errorf("failed to lock event log: %w", err)
+	}
+
+	// Tell the transactions change-feed trigger that this database
+	// transaction already emits its events, so it does not report them again.
+	if _, err := exec.ExecContext(ctx, `SELECT set_config('streaming.events_emitted', 'on', true)`); err != nil {
+		return fmt.Errorf("failed to flag emitted events: %w", err)
+	}
+
+	query := `
+		INSERT INTO transaction_event_log (event_id, event_type, aggregate_id, user_id, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING sequence
+	`
+
+	err := exec.QueryRowContext(
+		ctx,
+		query,
+		entry.EventID,
+		entry.EventType,
+		entry.AggregateID,
+		entry.UserID,
+		[]byte(entry.Payload),
+		entry.CreatedAt,
+	).Scan(&entry.Sequence)
+
+	if err != nil {
+		return fmt.Errorf("failed to append event log entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *eventLogRepositoryImpl) ListSince(ctx context.Context, userID uuid.UUID, since int64, limit int) ([]*entity.EventLogEntry, error) {
+	query := `
+		SELECT sequence, event_id, event_type, aggregate_id, user_id, payload, created_at
+		FROM transaction_event_log
+		WHERE sequence > $1 AND ($2 OR user_id = $3)
+		ORDER BY sequence
+		LIMIT $4
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, since, userID == uuid.Nil, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event log entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*entity.EventLogEntry
+	for rows.Next() {
+		entry := &entity.EventLogEntry{}
+		var payload []byte
+		err := rows.Scan(
+			&entry.Sequence,
+			&entry.EventID,
+			&entry.EventType,
+			&entry.AggregateID,
+			&entry.UserID,
+			&payload,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event log entry: %w", err)
+		}
+		entry.Payload = payload
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/repository"
+	"time"
+)
+
+type fxRateRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewFXRateRepository(db *sql.DB) repository.FXRateRepository {
+	return &fxRateRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *fxRateRepositoryImpl) Save(ctx context.Context, rates []*entity.FXRate) error {
+	query := `
+		INSERT INTO fx_rates (base_currency, quote_currency, rate, effective_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (base_currency, quote_currency, effective_at) DO UPDATE
+		SET rate = EXCLUDED.rate, created_at = EXCLUDED.created_at
+		RETURNING id
+	`
+
+	exec := executor(ctx, r.db)
+	for _, rate := range rates {
+		err := exec.QueryRowContext(
+			ctx,
+			query,
+			rate.BaseCurrency,
+			rate.QuoteCurrency,
+			rate.Rate,
+			rate.EffectiveAt,
+			rate.CreatedAt,
+		).Scan(&rate.ID)
+		if err != nil {
+			return fmt.Errorf("failed to save exchange rate: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *fxRateRepositoryImpl) GetEffective(ctx context.Context, base, quote string, at time.Time) (*entity.FXRate, error) {
+	query := `
+		SELECT id, base_currency, quote_currency, rate, effective_at, created_at
+		FROM fx_rates
+		WHERE base_currency = $1 AND quote_currency = $2 AND effective_at <= $3
+		ORDER BY effective_at DESC
+		LIMIT 1
+	`
+
+	rate := &entity.FXRate{}
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, base, quote, at).Scan(
+		&rate.ID,
+		&rate.BaseCurrency,
+		&rate.QuoteCurrency,
+		&rate.Rate,
+		&rate.EffectiveAt,
+		&rate.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrFXRateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+
+	return rate, nil
+}
+
+func (r *fxRateRepositoryImpl) List(ctx context.Context, base, quote string, limit int) ([]*entity.FXRate, error) {
+	query := `
+		SELECT id, base_currency, quote_currency, rate, effective_at, created_at
+		FROM fx_rates
+		WHERE ($1 = '' OR base_currency = $1)
+		  AND ($2 = '' OR quote_currency = $2)
+		ORDER BY effective_at DESC, base_currency, quote_currency
+		LIMIT $3
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, base, quote, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []*entity.FXRate
+	for rows.Next() {
+		rate := &entity.FXRate{}
+		err := rows.Scan(
+			&rate.ID,
+			&rate.BaseCurrency,
+			&rate.QuoteCurrency,
+			&rate.Rate,
+			&rate.EffectiveAt,
+			&rate.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type idempotencyKeyRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewIdempotencyKeyRepository(db *sql.DB) repository.IdempotencyKeyRepository {
+	return &idempotencyKeyRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *idempotencyKeyRepositoryImpl) Claim(ctx context.Context, key *entity.IdempotencyKey) (bool, error) {
+	// An expired key is taken over as if it had never been used.
+	query := `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			response = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		key.UserID,
+		key.Key,
+		key.RequestHash,
+		key.CreatedAt,
+		key.ExpiresAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *idempotencyKeyRepositoryImpl) Get(ctx context.Context, userID uuid.UUID, key string) (*entity.IdempotencyKey, error) {
+	query := `
+		SELECT user_id, idempotency_key, request_hash, response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+
+	record := &entity.IdempotencyKey{}
+	var response []byte
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, userID, key).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.RequestHash,
+		&response,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	record.Response = response
+
+	return record, nil
+}
+
+func (r *idempotencyKeyRepositoryImpl) SaveResponse(ctx context.Context, userID uuid.UUID, key string, response json.RawMessage) error {
+	query := `
+		UPDATE idempotency_keys
+		SET response = $3
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, userID, key, []byte(response)); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+func (r *idempotencyKeyRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time, limit int) (int, error) {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE (user_id, idempotency_key) IN (
+			SELECT user_id, idempotency_key
+			FROM idempotency_keys
+			WHERE expires_at <= $1
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/money"
+	"go-api-streaming/domain/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ledgerRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) repository.LedgerRepository {
+	return &ledgerRepositoryImpl{
+		db: db,
+	}
+}
+
+// Unsettled transactions of these types hold funds until they settle.
+const (
+	reservingTransactionTypes    = `('` + entity.TransactionTypeWithdraw + `', '` + entity.TransactionTypePurchase + `', '` + entity.TransactionTypeReversal + `')`
+	reservingTransactionStatuses = `('` + entity.TransactionStatusPending + `', '` + entity.TransactionStatusProcessing + `')`
+)
+
+func (r *ledgerRepositoryImpl) LockWallet(ctx context.Context, userID uuid.UUID, currency string) (*entity.LedgerAccount, error) {
+	insert := `
+		INSERT INTO ledger_accounts (id, user_id, name, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, currency) WHERE user_id IS NOT NULL DO NOTHING
+	`
+
+	exec := executor(ctx, r.db)
+	if _, err := exec.ExecContext(ctx, insert, uuid.New(), userID, entity.LedgerAccountWallet, currency, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to create wallet: %w", err)
+	}
+
+	query := `
+		SELECT id, user_id, name, currency, created_at
+		FROM ledger_accounts
+		WHERE user_id = $1 AND currency = $2
+		FOR UPDATE
+	`
+
+	account := &entity.LedgerAccount{}
+	err := exec.QueryRowContext(ctx, query, userID, currency).Scan(
+		&account.ID,
+		&account.UserID,
+		&account.Name,
+		&account.Currency,
+		&account.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock wallet: %w", err)
+	}
+
+	return account, nil
+}
+
+func (r *ledgerRepositoryImpl) GetSystemAccount(ctx context.Context, name, currency string) (*entity.LedgerAccount, error) {
+	insert := `
+		INSERT INTO ledger_accounts (id, user_id, name, currency, created_at)
+		VALUES ($1, NULL, $2, $3, $4)
+		ON CONFLICT (name, currency) WHERE user_id IS NULL DO NOTHING
+	`
+
+	exec := executor(ctx, r.db)
+	if _, err := exec.ExecContext(ctx, insert, uuid.New(), name, currency, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to create ledger account: %w", err)
+	}
+
+	query := `
+		SELECT id, user_id, name, currency, created_at
+		FROM ledger_accounts
+		WHERE user_id IS NULL AND name = $1 AND currency = $2
+	`
+
+	account := &entity.LedgerAccount{}
+	err := exec.QueryRowContext(ctx, query, name, currency).Scan(
+		&account.ID,
+		&account.UserID,
+		&account.Name,
+		&account.Currency,
+		&account.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger account: %w", err)
+	}
+
+	return account, nil
+}
+
+func (r *ledgerRepositoryImpl) Balance(ctx context.Context, accountID uuid.UUID) (money.Amount, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)
+		FROM ledger_entries
+		WHERE account_id = $1
+	`
+
+	var balance money.Amount
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, accountID).Scan(&balance); err != nil {
+		return money.Zero, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	return balance, nil
+}
+
+func (r *ledgerRepositoryImpl) ReservedAmount(ctx context.Context, userID uuid.UUID, currency string) (money.Amount, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE user_id = $1 AND currency = $2 AND status IN ` + reservingTransactionStatuses + `
+		  AND transaction_type IN ` + reservingTransactionTypes
+
+	var reserved money.Amount
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, userID, currency).Scan(&reserved); err != nil {
+		return money.Zero, fmt.Errorf("failed to get reserved amount: %w", err)
+	}
+
+	return reserved, nil
+}
+
+func (r *ledgerRepositoryImpl) HasEntries(ctx context.Context, transactionID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM ledger_entries WHERE transaction_id = $1)`
+
+	var exists bool
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, transactionID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check ledger entries: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (r *ledgerRepositoryImpl) ListEntries(ctx context.Context, transactionID uuid.UUID) ([]*entity.LedgerEntry, error) {
+	query := `
+		SELECT id, transaction_id, account_id, direction, amount, currency, reversal, created_at
+		FROM ledger_entries
+		WHERE transaction_id = $1
+		ORDER BY id
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*entity.LedgerEntry
+	for rows.Next() {
+		entry := &entity.LedgerEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TransactionID,
+			&entry.AccountID,
+			&entry.Direction,
+			&entry.Amount,
+			&entry.Currency,
+			&entry.Reversal,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *ledgerRepositoryImpl) CreateEntries(ctx context.Context, entries []*entity.LedgerEntry) error {
+	query := `
+		INSERT INTO ledger_entries (transaction_id, account_id, direction, amount, currency, reversal, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	exec := executor(ctx, r.db)
+	for _, entry := range entries {
+		err := exec.QueryRowContext(
+			ctx,
+			query,
+			entry.TransactionID,
+			entry.AccountID,
+			entry.Direction,
+			entry.Amount,
+			entry.Currency,
+			entry.Reversal,
+			entry.CreatedAt,
+		).Scan(&entry.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create ledger entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *ledgerRepositoryImpl) ListWallets(ctx context.Context, userID uuid.UUID) ([]*entity.Wallet, error) {
+	query := `
+		SELECT a.currency,
+			COALESCE((
+				SELECT SUM(CASE WHEN e.direction = 'credit' THEN e.amount ELSE -e.amount END)
+				FROM ledger_entries e
+				WHERE e.account_id = a.id
+			), 0),
+			COALESCE((
+				SELECT SUM(t.amount)
+				FROM transactions t
+				WHERE t.user_id = a.user_id AND t.currency = a.currency AND t.status IN ` + reservingTransactionStatuses + `
+				  AND t.transaction_type IN ` + reservingTransactionTypes + `
+			), 0)
+		FROM ledger_accounts a
+		WHERE a.user_id = $1
+		ORDER BY a.currency
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*entity.Wallet
+	for rows.Next() {
+		wallet := &entity.Wallet{}
+		if err := rows.Scan(&wallet.Currency, &wallet.Balance, &wallet.Reserved); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet: %w", err)
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return wallets, nil
+}
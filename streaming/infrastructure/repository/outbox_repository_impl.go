@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+type outboxRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) repository.OutboxRepository {
+	return &outboxRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *outboxRepositoryImpl) Create(ctx context.Context, event *entity.OutboxEvent) error {
+	query := `
+		INSERT INTO outbox_events (id, aggregate_type, aggregate_id, event_type, exchange, routing_key, payload, correlation_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := executor(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		event.ID,
+		event.AggregateType,
+		event.AggregateID,
+		event.EventType,
+		event.Exchange,
+		event.RoutingKey,
+		[]byte(event.Payload),
+		event.CorrelationID,
+		event.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create outbox event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepositoryImpl) LockPending(ctx context.Context, limit int) ([]*entity.OutboxEvent, error) {
+	query := `
+		SELECT id, aggregate_type, aggregate_id, event_type, exchange, routing_key, payload, correlation_id, attempts, last_error, created_at, published_at
+		FROM outbox_events
+		WHERE published_at IS NULL
+		ORDER BY created_at, id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*entity.OutboxEvent
+	for rows.Next() {
+		event := &entity.OutboxEvent{}
+		var payload []byte
+		err := rows.Scan(
+			&event.ID,
+			&event.AggregateType,
+			&event.AggregateID,
+			&event.EventType,
+			&event.Exchange,
+			&event.RoutingKey,
+			&payload,
+			&event.CorrelationID,
+			&event.Attempts,
+			&event.LastError,
+			&event.CreatedAt,
+			&event.PublishedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepositoryImpl) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE outbox_events
+		SET published_at = NOW(), attempts = attempts + 1, last_error = NULL
+		WHERE id = $1
+	`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark outbox event published: %w", err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepositoryImpl) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	query := `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = $1
+		WHERE id = $2
+	`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, reason, id); err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+
+	return nil
+}
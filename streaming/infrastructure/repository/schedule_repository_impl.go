@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type scheduleRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewScheduleRepository(db *sql.DB) repository.ScheduleRepository {
+	return &scheduleRepositoryImpl{
+		db: db,
+	}
+}
+
+const scheduleColumns = `id, user_id, amount, currency, transaction_type, description, recurrence, start_at, end_at, status, occurrence, next_run_at, last_transaction_id, last_error, retry_at, created_at, updated_at`
+
+func (r *scheduleRepositoryImpl) Create(ctx context.Context, schedule *entity.Schedule) error {
+	query := `
+		INSERT INTO schedules (` + scheduleColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	`
+
+	_, err := executor(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		schedule.ID,
+		schedule.UserID,
+		schedule.Amount,
+		schedule.Currency,
+		schedule.TransactionType,
+		schedule.Description,
+		schedule.Recurrence,
+		schedule.StartAt,
+		schedule.EndAt,
+		schedule.Status,
+		schedule.Occurrence,
+		schedule.NextRunAt,
+		schedule.LastTransactionID,
+		schedule.LastError,
+		schedule.RetryAt,
+		schedule.CreatedAt,
+		schedule.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	return nil
+}
+
+func (r *scheduleRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*entity.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE id = $1`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *scheduleRepositoryImpl) LockByID(ctx context.Context, id uuid.UUID) (*entity.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE id = $1 FOR UPDATE`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *scheduleRepositoryImpl) getOne(ctx context.Context, query string, id uuid.UUID) (*entity.Schedule, error) {
+	schedule, err := scanSchedule(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+func (r *scheduleRepositoryImpl) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*entity.Schedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM schedules
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	return r.query(ctx, query, userID)
+}
+
+func (r *scheduleRepositoryImpl) LockDue(ctx context.Context, now time.Time, limit int) ([]*entity.Schedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM schedules
+		WHERE status = $1 AND COALESCE(retry_at, next_run_at) <= $2
+		ORDER BY COALESCE(retry_at, next_run_at)
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	`
+
+	return r.query(ctx, query, entity.ScheduleStatusActive, now, limit)
+}
+
+func (r *scheduleRepositoryImpl) Update(ctx context.Context, schedule *entity.Schedule) error {
+	query := `
+		UPDATE schedules
+		SET amount = $1, description = $2, end_at = $3, status = $4, occurrence = $5, next_run_at = $6, last_transaction_id = $7, last_error = $8, retry_at = $9, updated_at = $10
+		WHERE id = $11
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		schedule.Amount,
+		schedule.Description,
+		schedule.EndAt,
+		schedule.Status,
+		schedule.Occurrence,
+		schedule.NextRunAt,
+		schedule.LastTransactionID,
+		schedule.LastError,
+		schedule.RetryAt,
+		schedule.UpdatedAt,
+		schedule.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrScheduleNotFound
+	}
+
+	return nil
+}
+
+func (r *scheduleRepositoryImpl) query(ctx context.Context, query string, args ...interface{}) ([]*entity.Schedule, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*entity.Schedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return schedules, nil
+}
+
+func scanSchedule(row rowScanner) (*entity.Schedule, error) {
+	schedule := &entity.Schedule{}
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.UserID,
+		&schedule.Amount,
+		&schedule.Currency,
+		&schedule.TransactionType,
+		&schedule.Description,
+		&schedule.Recurrence,
+		&schedule.StartAt,
+		&schedule.EndAt,
+		&schedule.Status,
+		&schedule.Occurrence,
+		&schedule.NextRunAt,
+		&schedule.LastTransactionID,
+		&schedule.LastError,
+		&schedule.RetryAt,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+type transactionHistoryRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewTransactionHistoryRepository(db *sql.DB) repository.TransactionHistoryRepository {
+	return &transactionHistoryRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *transactionHistoryRepositoryImpl) Append(ctx context.Context, change *entity.TransactionStatusChange) error {
+	query := `
+		INSERT INTO transaction_status_history (transaction_id, previous_status, status, actor_id, reason, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	err := executor(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		change.TransactionID,
+		change.PreviousStatus,
+		change.Status,
+		change.ActorID,
+		change.Reason,
+		change.RequestID,
+		change.CreatedAt,
+	).Scan(&change.ID)
+	if err != nil {
+		return fmt.Errorf("failed to append status history: %w", err)
+	}
+
+	return nil
+}
+
+func (r *transactionHistoryRepositoryImpl) ListByTransactionID(ctx context.Context, transactionID uuid.UUID) ([]*entity.TransactionStatusChange, error) {
+	query := `
+		SELECT id, transaction_id, previous_status, status, actor_id, reason, request_id, created_at
+		FROM transaction_status_history
+		WHERE transaction_id = $1
+		ORDER BY id
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*entity.TransactionStatusChange
+	for rows.Next() {
+		change := &entity.TransactionStatusChange{}
+		err := rows.Scan(
+			&change.ID,
+			&change.TransactionID,
+			&change.PreviousStatus,
+			&change.Status,
+			&change.ActorID,
+			&change.Reason,
+			&change.RequestID,
+			&change.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status history: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return changes, nil
+}
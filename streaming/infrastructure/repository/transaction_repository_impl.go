@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"go-api-streaming/domain/entity"
 	"go-api-streaming/domain/repository"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -22,11 +23,11 @@ func NewTransactionRepository(db *sql.DB) repository.TransactionRepository {
 
 func (r *transactionRepositoryImpl) Create(ctx context.Context, transaction *entity.Transaction) error {
 	query := `
-		INSERT INTO transactions (id, user_id, amount, currency, transaction_type, status, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO transactions (id, user_id, amount, currency, transaction_type, status, status_reason, parent_transaction_id, transfer_id, target_amount, target_currency, exchange_rate, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 
-	_, err := r.db.ExecContext(
+	_, err := executor(ctx, r.db).ExecContext(
 		ctx,
 		query,
 		transaction.ID,
@@ -35,6 +36,12 @@ func (r *transactionRepositoryImpl) Create(ctx context.Context, transaction *ent
 		transaction.Currency,
 		transaction.TransactionType,
 		transaction.Status,
+		transaction.StatusReason,
+		transaction.ParentTransactionID,
+		transaction.TransferID,
+		transaction.TargetAmount,
+		transaction.TargetCurrency,
+		transaction.ExchangeRate,
 		transaction.Description,
 		transaction.CreatedAt,
 		transaction.UpdatedAt,
@@ -49,26 +56,47 @@ func (r *transactionRepositoryImpl) Create(ctx context.Context, transaction *ent
 
 func (r *transactionRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*entity.Transaction, error) {
 	query := `
-		SELECT id, user_id, amount, currency, transaction_type, status, description, created_at, updated_at
+		SELECT id, user_id, amount, currency, transaction_type, status, status_reason, parent_transaction_id, transfer_id, target_amount, target_currency, exchange_rate, description, created_at, updated_at
 		FROM transactions
 		WHERE id = $1
 	`
 
+	return r.getOne(ctx, query, id)
+}
+
+func (r *transactionRepositoryImpl) LockByID(ctx context.Context, id uuid.UUID) (*entity.Transaction, error) {
+	query := `
+		SELECT id, user_id, amount, currency, transaction_type, status, status_reason, parent_transaction_id, transfer_id, target_amount, target_currency, exchange_rate, description, created_at, updated_at
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *transactionRepositoryImpl) getOne(ctx context.Context, query string, id uuid.UUID) (*entity.Transaction, error) {
 	transaction := &entity.Transaction{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&transaction.ID,
 		&transaction.UserID,
 		&transaction.Amount,
 		&transaction.Currency,
 		&transaction.TransactionType,
 		&transaction.Status,
+		&transaction.StatusReason,
+		&transaction.ParentTransactionID,
+		&transaction.TransferID,
+		&transaction.TargetAmount,
+		&transaction.TargetCurrency,
+		&transaction.ExchangeRate,
 		&transaction.Description,
 		&transaction.CreatedAt,
 		&transaction.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("transaction not found")
+		return nil, repository.ErrTransactionNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction: %w", err)
@@ -79,14 +107,31 @@ func (r *transactionRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (
 
 func (r *transactionRepositoryImpl) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*entity.Transaction, error) {
 	query := `
-		SELECT id, user_id, amount, currency, transaction_type, status, description, created_at, updated_at
+		SELECT id, user_id, amount, currency, transaction_type, status, status_reason, parent_transaction_id, transfer_id, target_amount, target_currency, exchange_rate, description, created_at, updated_at
 		FROM transactions
 		WHERE user_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanTransactions(rows)
+}
+
+func (r *transactionRepositoryImpl) GetByParentID(ctx context.Context, parentID uuid.UUID) ([]*entity.Transaction, error) {
+	query := `
+		SELECT id, user_id, amount, currency, transaction_type, status, status_reason, parent_transaction_id, transfer_id, target_amount, target_currency, exchange_rate, description, created_at, updated_at
+		FROM transactions
+		WHERE parent_transaction_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, parentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -98,17 +143,18 @@ func (r *transactionRepositoryImpl) GetByUserID(ctx context.Context, userID uuid
 func (r *transactionRepositoryImpl) Update(ctx context.Context, transaction *entity.Transaction) error {
 	query := `
 		UPDATE transactions
-		SET amount = $1, currency = $2, transaction_type = $3, status = $4, description = $5, updated_at = $6
-		WHERE id = $7
+		SET amount = $1, currency = $2, transaction_type = $3, status = $4, status_reason = $5, description = $6, updated_at = $7
+		WHERE id = $8
 	`
 
-	result, err := r.db.ExecContext(
+	result, err := executor(ctx, r.db).ExecContext(
 		ctx,
 		query,
 		transaction.Amount,
 		transaction.Currency,
 		transaction.TransactionType,
 		transaction.Status,
+		transaction.StatusReason,
 		transaction.Description,
 		transaction.UpdatedAt,
 		transaction.ID,
@@ -124,7 +170,7 @@ func (r *transactionRepositoryImpl) Update(ctx context.Context, transaction *ent
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("transaction not found")
+		return repository.ErrTransactionNotFound
 	}
 
 	return nil
@@ -132,13 +178,13 @@ func (r *transactionRepositoryImpl) Update(ctx context.Context, transaction *ent
 
 func (r *transactionRepositoryImpl) GetAll(ctx context.Context, limit, offset int) ([]*entity.Transaction, error) {
 	query := `
-		SELECT id, user_id, amount, currency, transaction_type, status, description, created_at, updated_at
+		SELECT id, user_id, amount, currency, transaction_type, status, status_reason, parent_transaction_id, transfer_id, target_amount, target_currency, exchange_rate, description, created_at, updated_at
 		FROM transactions
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -149,14 +195,14 @@ func (r *transactionRepositoryImpl) GetAll(ctx context.Context, limit, offset in
 
 func (r *transactionRepositoryImpl) GetByStatus(ctx context.Context, status string, limit, offset int) ([]*entity.Transaction, error) {
 	query := `
-		SELECT id, user_id, amount, currency, transaction_type, status, description, created_at, updated_at
+		SELECT id, user_id, amount, currency, transaction_type, status, status_reason, parent_transaction_id, transfer_id, target_amount, target_currency, exchange_rate, description, created_at, updated_at
 		FROM transactions
 		WHERE status = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, status, limit, offset)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, status, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -165,11 +211,30 @@ func (r *transactionRepositoryImpl) GetByStatus(ctx context.Context, status stri
 	return r.scanTransactions(rows)
 }
 
+func (r *transactionRepositoryImpl) LockExpiredPending(ctx context.Context, transactionType string, createdBefore time.Time, limit int) ([]*entity.Transaction, error) {
+	query := `
+		SELECT id, user_id, amount, currency, transaction_type, status, status_reason, parent_transaction_id, transfer_id, target_amount, target_currency, exchange_rate, description, created_at, updated_at
+		FROM transactions
+		WHERE status = $1 AND transaction_type = $2 AND created_at < $3
+		ORDER BY created_at
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, entity.TransactionStatusPending, transactionType, createdBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock expired transactions: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanTransactions(rows)
+}
+
 func (r *transactionRepositoryImpl) UserExists(ctx context.Context, userID uuid.UUID) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`
 	var exists bool
 	
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(&exists)
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check user existence: %w", err)
 	}
@@ -177,24 +242,87 @@ func (r *transactionRepositoryImpl) UserExists(ctx context.Context, userID uuid.
 	return exists, nil
 }
 
+func (r *transactionRepositoryImpl) Stream(ctx context.Context, filter repository.TransactionFilter, fn func(*entity.Transaction) error) error {
+	query := `
+		SELECT id, user_id, amount, currency, transaction_type, status, status_reason, parent_transaction_id, transfer_id, target_amount, target_currency, exchange_rate, description, created_at, updated_at
+		FROM transactions
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+		  AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+	`
+
+	var userID interface{}
+	if filter.UserID != uuid.Nil {
+		userID = filter.UserID
+	}
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID, filter.Status)
+	if err != nil {
+		return fmt.Errorf("failed to stream transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return nil
+}
+
+func (r *transactionRepositoryImpl) Summarize(ctx context.Context, filter repository.TransactionFilter) ([]*entity.TransactionTotal, error) {
+	query := `
+		SELECT transaction_type, currency, COUNT(*), COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+		  AND ($2 = '' OR status = $2)
+		GROUP BY transaction_type, currency
+		ORDER BY transaction_type, currency
+	`
+
+	var userID interface{}
+	if filter.UserID != uuid.Nil {
+		userID = filter.UserID
+	}
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID, filter.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []*entity.TransactionTotal
+	for rows.Next() {
+		total := &entity.TransactionTotal{}
+		if err := rows.Scan(&total.TransactionType, &total.Currency, &total.Count, &total.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction total: %w", err)
+		}
+		totals = append(totals, total)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return totals, nil
+}
+
 func (r *transactionRepositoryImpl) scanTransactions(rows *sql.Rows) ([]*entity.Transaction, error) {
 	var transactions []*entity.Transaction
 
 	for rows.Next() {
-		transaction := &entity.Transaction{}
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.UserID,
-			&transaction.Amount,
-			&transaction.Currency,
-			&transaction.TransactionType,
-			&transaction.Status,
-			&transaction.Description,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-		)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+			return nil, err
 		}
 		transactions = append(transactions, transaction)
 	}
@@ -205,3 +333,28 @@ func (r *transactionRepositoryImpl) scanTransactions(rows *sql.Rows) ([]*entity.
 
 	return transactions, nil
 }
+
+func scanTransaction(rows *sql.Rows) (*entity.Transaction, error) {
+	transaction := &entity.Transaction{}
+	err := rows.Scan(
+		&transaction.ID,
+		&transaction.UserID,
+		&transaction.Amount,
+		&transaction.Currency,
+		&transaction.TransactionType,
+		&transaction.Status,
+		&transaction.StatusReason,
+		&transaction.ParentTransactionID,
+		&transaction.TransferID,
+		&transaction.TargetAmount,
+		&transaction.TargetCurrency,
+		&transaction.ExchangeRate,
+		&transaction.Description,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan transaction: %w", err)
+	}
+	return transaction, nil
+}
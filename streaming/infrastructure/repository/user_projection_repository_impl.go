@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type userProjectionRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewUserProjectionRepository(db *sql.DB) repository.UserProjectionRepository {
+	return &userProjectionRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *userProjectionRepositoryImpl) Upsert(ctx context.Context, user *entity.UserProjection) error {
+	query := `
+		INSERT INTO user_projections (id, email, name, deleted, last_event_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE
+		SET email = EXCLUDED.email, name = EXCLUDED.name, deleted = EXCLUDED.deleted,
+			last_event_at = EXCLUDED.last_event_at, updated_at = EXCLUDED.updated_at
+		WHERE user_projections.last_event_at <= EXCLUDED.last_event_at
+	`
+
+	_, err := executor(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		user.ID,
+		user.Email,
+		user.Name,
+		user.Deleted,
+		user.LastEventAt,
+		user.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to upsert user projection: %w", err)
+	}
+
+	return nil
+}
+
+func (r *userProjectionRepositoryImpl) MarkDeleted(ctx context.Context, id uuid.UUID, eventAt time.Time) error {
+	// A delete may arrive before the user was ever seen, so insert a
+	// tombstone rather than updating nothing.
+	query := `
+		INSERT INTO user_projections (id, email, name, deleted, last_event_at, updated_at)
+		VALUES ($1, '', '', TRUE, $2, NOW())
+		ON CONFLICT (id) DO UPDATE
+		SET deleted = TRUE, last_event_at = EXCLUDED.last_event_at, updated_at = NOW()
+		WHERE user_projections.last_event_at <= EXCLUDED.last_event_at
+	`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id, eventAt); err != nil {
+		return fmt.Errorf("failed to mark user projection deleted: %w", err)
+	}
+
+	return nil
+}
+
+func (r *userProjectionRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*entity.UserProjection, error) {
+	query := `
+		SELECT id, email, name, deleted, last_event_at, updated_at
+		FROM user_projections
+		WHERE id = $1
+	`
+
+	user := &entity.UserProjection{}
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.Deleted,
+		&user.LastEventAt,
+		&user.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user projection: %w", err)
+	}
+
+	return user, nil
+}
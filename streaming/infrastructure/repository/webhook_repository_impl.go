@@ -0,0 +1,423 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/repository"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type webhookRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) repository.WebhookRepository {
+	return &webhookRepositoryImpl{
+		db: db,
+	}
+}
+
+const webhookSubscriptionColumns = `id, user_id, url, event_types, secret, active, failure_count, disabled_at, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, retry_base, next_attempt_at, last_error, created_at, updated_at`
+
+func (r *webhookRepositoryImpl) CreateSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (id, user_id, url, event_types, secret, active, failure_count, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := executor(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		subscription.ID,
+		subscription.UserID,
+		subscription.URL,
+		pq.Array(subscription.EventTypes),
+		subscription.Secret,
+		subscription.Active,
+		subscription.FailureCount,
+		subscription.CreatedAt,
+		subscription.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepositoryImpl) GetSubscription(ctx context.Context, id uuid.UUID) (*entity.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	subscription, err := scanWebhookSubscription(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+func (r *webhookRepositoryImpl) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]*entity.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	return r.querySubscriptions(ctx, query, userID)
+}
+
+func (r *webhookRepositoryImpl) ListActiveSubscriptions(ctx context.Context, userID uuid.UUID, eventType string) ([]*entity.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE user_id = $1 AND active AND $2 = ANY(event_types)
+	`
+
+	return r.querySubscriptions(ctx, query, userID, eventType)
+}
+
+func (r *webhookRepositoryImpl) UpdateSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $1, event_types = $2, secret = $3, active = $4, failure_count = $5, disabled_at = $6, updated_at = $7
+		WHERE id = $8
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		subscription.URL,
+		pq.Array(subscription.EventTypes),
+		subscription.Secret,
+		subscription.Active,
+		subscription.FailureCount,
+		subscription.DisabledAt,
+		subscription.UpdatedAt,
+		subscription.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+
+	return requireWebhookRowsAffected(result)
+}
+
+func (r *webhookRepositoryImpl) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM webhook_subscriptions WHERE id = $1`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	return requireWebhookRowsAffected(result)
+}
+
+func (r *webhookRepositoryImpl) RecordSubscriptionSuccess(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE webhook_subscriptions SET failure_count = 0 WHERE id = $1 AND failure_count <> 0`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to record webhook success: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepositoryImpl) RecordSubscriptionFailure(ctx context.Context, id uuid.UUID, disableAfter int) (bool, error) {
+	query := `
+		UPDATE webhook_subscriptions
+		SET failure_count = failure_count + 1,
+			active = active AND failure_count + 1 < $1,
+			disabled_at = CASE WHEN active AND failure_count + 1 >= $1 THEN NOW() ELSE disabled_at END,
+			updated_at = NOW()
+		WHERE id = $2
+		RETURNING NOT active AND failure_count = $1
+	`
+
+	var disabled bool
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, disableAfter, id).Scan(&disabled); err != nil {
+		return false, fmt.Errorf("failed to record webhook failure: %w", err)
+	}
+
+	return disabled, nil
+}
+
+func (r *webhookRepositoryImpl) CreateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status, attempts, retry_base, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+
+	_, err := executor(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		delivery.ID,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventType,
+		[]byte(delivery.Payload),
+		delivery.Status,
+		delivery.Attempts,
+		delivery.RetryBase,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+		delivery.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepositoryImpl) LockDueDeliveries(ctx context.Context, limit int) ([]*entity.WebhookDelivery, error) {
+	query := `
+		SELECT ` + prefixColumns("d", webhookDeliveryColumns) + `
+		FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND s.active
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT $1
+		FOR UPDATE OF d SKIP LOCKED
+	`
+
+	return r.queryDeliveries(ctx, query, limit)
+}
+
+func (r *webhookRepositoryImpl) GetDelivery(ctx context.Context, id, subscriptionID uuid.UUID) (*entity.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1 AND subscription_id = $2`
+
+	deliveries, err := r.queryDeliveries(ctx, query, id, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, repository.ErrWebhookNotFound
+	}
+
+	return deliveries[0], nil
+}
+
+func (r *webhookRepositoryImpl) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]*entity.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	return r.queryDeliveries(ctx, query, subscriptionID, limit)
+}
+
+func (r *webhookRepositoryImpl) UpdateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, retry_base = $3, next_attempt_at = $4, last_error = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.RetryBase,
+		delivery.NextAttemptAt,
+		delivery.LastError,
+		delivery.UpdatedAt,
+		delivery.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	return requireWebhookRowsAffected(result)
+}
+
+func (r *webhookRepositoryImpl) CreateAttempt(ctx context.Context, attempt *entity.WebhookDeliveryAttempt) error {
+	query := `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := executor(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		attempt.DeliveryID,
+		attempt.Attempt,
+		attempt.StatusCode,
+		attempt.Error,
+		attempt.DurationMs,
+		attempt.CreatedAt,
+	).Scan(&attempt.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepositoryImpl) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*entity.WebhookDeliveryAttempt, error) {
+	query := `
+		SELECT id, delivery_id, attempt, status_code, error, duration_ms, created_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY id
+	`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*entity.WebhookDeliveryAttempt
+	for rows.Next() {
+		attempt := &entity.WebhookDeliveryAttempt{}
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.DeliveryID,
+			&attempt.Attempt,
+			&attempt.StatusCode,
+			&attempt.Error,
+			&attempt.DurationMs,
+			&attempt.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return attempts, nil
+}
+
+func (r *webhookRepositoryImpl) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]*entity.WebhookSubscription, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*entity.WebhookSubscription
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (r *webhookRepositoryImpl) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*entity.WebhookDelivery, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*entity.WebhookDelivery
+	for rows.Next() {
+		delivery := &entity.WebhookDelivery{}
+		var payload []byte
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventID,
+			&delivery.EventType,
+			&payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.RetryBase,
+			&delivery.NextAttemptAt,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		delivery.Payload = payload
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhookSubscription(row rowScanner) (*entity.WebhookSubscription, error) {
+	subscription := &entity.WebhookSubscription{}
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.UserID,
+		&subscription.URL,
+		pq.Array(&subscription.EventTypes),
+		&subscription.Secret,
+		&subscription.Active,
+		&subscription.FailureCount,
+		&subscription.DisabledAt,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// prefixColumns qualifies a comma-separated column list with a table alias.
+func prefixColumns(alias, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i, column := range parts {
+		parts[i] = alias + "." + column
+	}
+	return strings.Join(parts, ", ")
+}
+
+// requireWebhookRowsAffected maps an update or delete that matched nothing to
+// ErrWebhookNotFound.
+func requireWebhookRowsAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return repository.ErrWebhookNotFound
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package stream
+
+import (
+	"errors"
+	"go-api-streaming/domain/events"
+	"log"
+	"sync"
+)
+
+// ErrSlowSubscriber is reported by Subscription.Err when the subscription was
+// dropped because its buffer filled up.
+var ErrSlowSubscriber = errors.New("stream: subscriber too slow, dropped")
+
+// Filter decides whether a subscription receives an event.
+type Filter func(envelope *events.Envelope) bool
+
+// Hub fans events out to in-process subscribers. Publishing never blocks: a
+// subscriber whose buffer is full is dropped instead of stalling the others.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives the events matching its filter on C until it is
+// closed, either by the subscriber or by the hub.
+type Subscription struct {
+	C <-chan *events.Envelope
+
+	hub    *Hub
+	ch     chan *events.Envelope
+	filter Filter
+	once   sync.Once
+	err    error
+}
+
+// Subscribe registers a subscription with room for buffer pending events.
+func (h *Hub) Subscribe(filter Filter, buffer int) *Subscription {
+	ch := make(chan *events.Envelope, buffer)
+	sub := &Subscription{
+		C:      ch,
+		hub:    h,
+		ch:     ch,
+		filter: filter,
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Publish delivers envelope to every matching subscription.
+func (h *Hub) Publish(envelope *events.Envelope) {
+	var slow []*Subscription
+
+	h.mu.RLock()
+	for sub := range h.subscribers {
+		if sub.filter != nil && !sub.filter(envelope) {
+			continue
+		}
+		select {
+		case sub.ch <- envelope:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, sub := range slow {
+		log.Printf("Dropping slow stream subscriber")
+		sub.close(ErrSlowSubscriber)
+	}
+}
+
+// Close unsubscribes. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.close(nil)
+}
+
+// Err returns why the hub closed the subscription, or nil.
+func (s *Subscription) Err() error {
+	s.hub.mu.RLock()
+	defer s.hub.mu.RUnlock()
+	return s.err
+}
+
+func (s *Subscription) close(err error) {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		delete(s.hub.subscribers, s)
+		s.err = err
+		close(s.ch)
+		s.hub.mu.Unlock()
+	})
+}
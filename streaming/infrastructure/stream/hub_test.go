@@ -0,0 +1,138 @@
+package stream
+
+import (
+	"go-api-streaming/domain/events"
+	"sync"
+	"testing"
+)
+
+func TestHubFilters(t *testing.T) {
+	hub := NewHub()
+
+	created := hub.Subscribe(func(envelope *events.Envelope) bool {
+		return envelope.Type == events.TypeTransactionCreated
+	}, 10)
+	defer created.Close()
+	all := hub.Subscribe(nil, 10)
+	defer all.Close()
+
+	published := []*events.Envelope{
+		{ID: "1", Type: events.TypeTransactionCreated},
+		{ID: "2", Type: events.TypeTransactionUpdated},
+		{ID: "3", Type: events.TypeTransactionCreated},
+	}
+	for _, envelope := range published {
+		hub.Publish(envelope)
+	}
+
+	tests := []struct {
+		name string
+		sub  *Subscription
+		want []string
+	}{
+		{name: "created only", sub: created, want: []string{"1", "3"}},
+		{name: "no filter", sub: all, want: []string{"1", "2", "3"}},
+	}
+
+	for _, tt := range tests {
+		got := drain(tt.sub)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: received %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: received %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	slow := hub.Subscribe(nil, 1)
+	fast := hub.Subscribe(nil, 3)
+	defer fast.Close()
+
+	for _, id := range []string{"1", "2", "3"} {
+		hub.Publish(&events.Envelope{ID: id})
+	}
+
+	// The slow subscriber keeps what it buffered, then its channel closes.
+	if got := drain(slow); len(got) != 1 || got[0] != "1" {
+		t.Errorf("slow subscriber received %v, want [1]", got)
+	}
+	if _, ok := <-slow.C; ok {
+		t.Errorf("slow subscriber channel is open, want it closed")
+	}
+	if err := slow.Err(); err != ErrSlowSubscriber {
+		t.Errorf("slow subscriber Err = %v, want ErrSlowSubscriber", err)
+	}
+
+	if got := drain(fast); len(got) != 3 {
+		t.Errorf("fast subscriber received %v, want 3 events", got)
+	}
+	if err := fast.Err(); err != nil {
+		t.Errorf("fast subscriber Err = %v, want nil", err)
+	}
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(nil, 1)
+
+	sub.Close()
+	sub.Close()
+
+	if _, ok := <-sub.C; ok {
+		t.Errorf("channel is open after Close, want it closed")
+	}
+	if err := sub.Err(); err != nil {
+		t.Errorf("Err after Close = %v, want nil", err)
+	}
+
+	// Publishing after the only subscriber left must not panic or block.
+	hub.Publish(&events.Envelope{ID: "1"})
+	if n := len(hub.subscribers); n != 0 {
+		t.Errorf("hub has %d subscribers after Close, want 0", n)
+	}
+}
+
+func TestHubConcurrentPublishAndClose(t *testing.T) {
+	hub := NewHub()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				sub := hub.Subscribe(nil, 1)
+				hub.Publish(&events.Envelope{ID: "x"})
+				sub.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(hub.subscribers); n != 0 {
+		t.Errorf("hub has %d subscribers left, want 0", n)
+	}
+}
+
+// drain returns the IDs of the events buffered on sub without blocking.
+func drain(sub *Subscription) []string {
+	var ids []string
+	for {
+		select {
+		case envelope, ok := <-sub.C:
+			if !ok {
+				return ids
+			}
+			ids = append(ids, envelope.ID)
+		default:
+			return ids
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every delivery. The signature is an HMAC-SHA256 over
+// "<timestamp>.<body>" keyed with the subscription's secret, so receivers can
+// verify the sender and reject replays with a stale timestamp.
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderEvent     = "X-Webhook-Event"
+)
+
+// Request is a single webhook call.
+type Request struct {
+	URL         string
+	Secret      string
+	DeliveryID  string
+	EventType   string
+	ContentType string
+	Body        []byte
+}
+
+// Client sends signed webhook requests. Unless allowPrivate is set, it only
+// connects to public addresses, so subscriptions cannot reach hosts inside
+// the network.
+type Client struct {
+	http         *http.Client
+	allowPrivate bool
+}
+
+func NewClient(timeout time.Duration, allowPrivate bool) *Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = checkDialedAddr
+	}
+
+	return &Client{
+		http: &http.Client{
+			Timeout: timeout,
+			// No proxy: the dialer must see the webhook's own address.
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConnsPerHost: 2,
+			},
+		},
+		allowPrivate: allowPrivate,
+	}
+}
+
+// Send posts the request and returns the response status code. Any status
+// outside 2xx is returned as an error along with the code; statusCode is zero
+// when no response was received.
+func (c *Client) Send(ctx context.Context, req Request) (statusCode int, err error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", req.ContentType)
+	httpReq.Header.Set("User-Agent", "streaming-service-webhooks")
+	httpReq.Header.Set(HeaderTimestamp, timestamp)
+	httpReq.Header.Set(HeaderSignature, "sha256="+Sign(req.Secret, timestamp, req.Body))
+	httpReq.Header.Set(HeaderDelivery, req.DeliveryID)
+	httpReq.Header.Set(HeaderEvent, req.EventType)
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain a little of the body so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint responded %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
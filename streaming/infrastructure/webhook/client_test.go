@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{
+			secret:    "whsec_test_secret",
+			timestamp: "1700000000",
+			body:      `{"id":"1"}`,
+			want:      "c10a092032b032422619ec21b90dd2523fd5b2be59ed619283f25aa7c1d57567",
+		},
+		{
+			secret:    "key",
+			timestamp: "0",
+			body:      "",
+			want:      "85841b4efc3cd7776c3c8f9b7cca9e281c550e5d19889d78e9e669c6337f000d",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %q, %q) = %s, want %s", tt.secret, tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+
+	if Sign("secret-a", "1", []byte("body")) == Sign("secret-b", "1", []byte("body")) {
+		t.Errorf("signatures with different secrets are equal")
+	}
+	if Sign("secret", "1", []byte("body")) == Sign("secret", "2", []byte("body")) {
+		t.Errorf("signatures with different timestamps are equal")
+	}
+}
+
+func TestSend(t *testing.T) {
+	const secret = "whsec_test_secret"
+	body := []byte(`{"type":"transaction.created"}`)
+
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(5*time.Second, true)
+	statusCode, err := client.Send(context.Background(), Request{
+		URL:         server.URL,
+		Secret:      secret,
+		DeliveryID:  "delivery-1",
+		EventType:   "transaction.created",
+		ContentType: "application/cloudevents+json",
+		Body:        body,
+	})
+	if err != nil {
+		t.Fatalf("Send error = %v", err)
+	}
+	if statusCode != http.StatusNoContent {
+		t.Errorf("status code = %d, want %d", statusCode, http.StatusNoContent)
+	}
+
+	timestamp := received.Header.Get(HeaderTimestamp)
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Errorf("%s = %q, want a Unix time", HeaderTimestamp, timestamp)
+	}
+	if got, want := received.Header.Get(HeaderSignature), "sha256="+Sign(secret, timestamp, body); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+	if got := received.Header.Get(HeaderDelivery); got != "delivery-1" {
+		t.Errorf("%s = %q, want delivery-1", HeaderDelivery, got)
+	}
+	if got := received.Header.Get(HeaderEvent); got != "transaction.created" {
+		t.Errorf("%s = %q, want transaction.created", HeaderEvent, got)
+	}
+	if got := received.Header.Get("Content-Type"); got != "application/cloudevents+json" {
+		t.Errorf("Content-Type = %q, want application/cloudevents+json", got)
+	}
+	if string(receivedBody) != string(body) {
+		t.Errorf("body = %s, want %s", receivedBody, body)
+	}
+}
+
+func TestSendNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	statusCode, err := NewClient(5*time.Second, true).Send(context.Background(), Request{URL: server.URL})
+	if err == nil {
+		t.Fatalf("Send succeeded, want an error for a 503 response")
+	}
+	if statusCode != http.StatusServiceUnavailable {
+		t.Errorf("status code = %d, want %d", statusCode, http.StatusServiceUnavailable)
+	}
+}
+
+func TestSendRejectsPrivateTarget(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	statusCode, err := NewClient(5*time.Second, false).Send(context.Background(), Request{URL: server.URL})
+	if !errors.Is(err, ErrForbiddenTarget) {
+		t.Errorf("Send to %s error = %v, want ErrForbiddenTarget", server.URL, err)
+	}
+	if statusCode != 0 {
+		t.Errorf("status code = %d, want 0", statusCode)
+	}
+	if called {
+		t.Errorf("the loopback server was called")
+	}
+}
@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "8.8.8.8", want: true},
+		{addr: "2606:4700:4700::1111", want: true},
+		{addr: "0.0.0.0", want: false},
+		{addr: "0.1.2.3", want: false},
+		{addr: "127.0.0.1", want: false},
+		{addr: "10.0.0.1", want: false},
+		{addr: "172.16.5.4", want: false},
+		{addr: "192.168.1.1", want: false},
+		{addr: "169.254.169.254", want: false},
+		{addr: "100.64.0.1", want: false},
+		{addr: "192.0.0.8", want: false},
+		{addr: "198.18.0.1", want: false},
+		{addr: "224.0.0.1", want: false},
+		{addr: "255.255.255.255", want: false},
+		{addr: "::", want: false},
+		{addr: "::1", want: false},
+		{addr: "fc00::1", want: false},
+		{addr: "fe80::1", want: false},
+		{addr: "ff02::1", want: false},
+		{addr: "::ffff:127.0.0.1", want: false},
+		{addr: "::ffff:10.0.0.1", want: false},
+		{addr: "::ffff:93.184.216.34", want: true},
+		{addr: "64:ff9b::a00:1", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublicAddr(%s) = %t, want %t", tt.addr, got, tt.want)
+		}
+	}
+
+	if isPublicAddr(netip.Addr{}) {
+		t.Errorf("isPublicAddr of the zero Addr = true, want false")
+	}
+}
+
+func TestCheckDialedAddr(t *testing.T) {
+	tests := []struct {
+		address       string
+		wantForbidden bool
+		wantErr       bool
+	}{
+		{address: "93.184.216.34:443"},
+		{address: "[2606:4700:4700::1111]:443"},
+		{address: "127.0.0.1:8080", wantForbidden: true},
+		{address: "[::1]:80", wantForbidden: true},
+		{address: "10.1.2.3:80", wantForbidden: true},
+		{address: "example.com:80", wantErr: true},
+		{address: "93.184.216.34", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := checkDialedAddr("tcp", tt.address, nil)
+		switch {
+		case tt.wantForbidden:
+			if !errors.Is(err, ErrForbiddenTarget) {
+				t.Errorf("checkDialedAddr(%s) error = %v, want ErrForbiddenTarget", tt.address, err)
+			}
+		case tt.wantErr:
+			if err == nil || errors.Is(err, ErrForbiddenTarget) {
+				t.Errorf("checkDialedAddr(%s) error = %v, want a parse error", tt.address, err)
+			}
+		default:
+			if err != nil {
+				t.Errorf("checkDialedAddr(%s) error = %v", tt.address, err)
+			}
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	// IP literals resolve to themselves, so no DNS lookup is made.
+	tests := []struct {
+		host          string
+		allowPrivate  bool
+		wantForbidden bool
+	}{
+		{host: "93.184.216.34"},
+		{host: "127.0.0.1", wantForbidden: true},
+		{host: "192.168.0.10", wantForbidden: true},
+		{host: "::1", wantForbidden: true},
+		{host: "127.0.0.1", allowPrivate: true},
+		{host: "10.0.0.1", allowPrivate: true},
+	}
+
+	for _, tt := range tests {
+		client := NewClient(time.Second, tt.allowPrivate)
+		err := client.CheckHost(context.Background(), tt.host)
+		if tt.wantForbidden {
+			if !errors.Is(err, ErrForbiddenTarget) {
+				t.Errorf("CheckHost(%s) error = %v, want ErrForbiddenTarget", tt.host, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("CheckHost(%s) with allowPrivate %t error = %v", tt.host, tt.allowPrivate, err)
+		}
+	}
+}
@@ -1,18 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"go-api-streaming/delivery/http/handler"
 	"go-api-streaming/delivery/http/middleware"
 	"go-api-streaming/delivery/http/router"
+	deliverymq "go-api-streaming/delivery/messaging"
 	"go-api-streaming/infrastructure/config"
 	"go-api-streaming/infrastructure/database"
 	"go-api-streaming/infrastructure/messaging"
 	"go-api-streaming/infrastructure/repository"
+	"go-api-streaming/infrastructure/stream"
+	"go-api-streaming/infrastructure/webhook"
 	"go-api-streaming/usecase"
+	"go-api-streaming/worker"
 	"log"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 func main() {
@@ -39,25 +45,134 @@ func main() {
 	}
 	defer rabbitmq.Close()
 
-	// Declare transaction events queue
-	if err := rabbitmq.DeclareQueue("transaction_events"); err != nil {
-		log.Fatalf("Failed to declare queue: %v", err)
+	// Declare transaction events exchange and the configured subscriber queues
+	if err := rabbitmq.DeclareExchangeWithAlternate(cfg.RabbitMQ.TransactionExchange, "topic", cfg.RabbitMQ.TransactionExchange+".unrouted"); err != nil {
+		log.Fatalf("Failed to declare exchange: %v", err)
+	}
+	for _, binding := range cfg.RabbitMQ.TransactionBindings {
+		if err := rabbitmq.DeclareQueue(binding.Queue); err != nil {
+			log.Fatalf("Failed to declare queue: %v", err)
+		}
+		if err := rabbitmq.BindQueue(binding.Queue, cfg.RabbitMQ.TransactionExchange, binding.RoutingKey); err != nil {
+			log.Fatalf("Failed to bind queue: %v", err)
+		}
 	}
 
 	// Initialize repositories
 	transactionRepo := repository.NewTransactionRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	eventLogRepo := repository.NewEventLogRepository(db)
+	userProjectionRepo := repository.NewUserProjectionRepository(db)
+	changeFeedRepo := repository.NewChangeFeedRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	ledgerRepo := repository.NewLedgerRepository(db)
+	idempotencyKeyRepo := repository.NewIdempotencyKeyRepository(db)
+	fxRateRepo := repository.NewFXRateRepository(db)
+	transactionHistoryRepo := repository.NewTransactionHistoryRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	txManager := repository.NewTxManager(db)
 
 	// Initialize use cases
-	transactionUseCase := usecase.NewTransactionUseCase(transactionRepo, rabbitmq)
+	eventPublisher := usecase.NewTransactionEventPublisher(outboxRepo, eventLogRepo, cfg.RabbitMQ.TransactionExchange)
+	walletUseCase := usecase.NewWalletUseCase(ledgerRepo)
+	fxUseCase := usecase.NewFXUseCase(fxRateRepo, txManager)
+	transactionUseCase := usecase.NewTransactionUseCase(transactionRepo, transactionHistoryRepo, eventPublisher, walletUseCase, fxUseCase, userProjectionRepo, idempotencyKeyRepo, txManager, cfg.Idempotency.KeyTTL, usecase.PendingExpiryPolicy{
+		DefaultTTL: cfg.Expiry.PendingTTL,
+		TTLByType:  cfg.Expiry.PendingTTLByType,
+	})
+	scheduleUseCase := usecase.NewScheduleUseCase(scheduleRepo, transactionUseCase, txManager)
+	changeFeedUseCase := usecase.NewChangeFeedUseCase(transactionRepo, transactionHistoryRepo, changeFeedRepo, eventPublisher, txManager)
+	userUseCase := usecase.NewUserUseCase(userProjectionRepo)
+	deadLetterUseCase := usecase.NewDeadLetterUseCase(rabbitmq)
+	webhookUseCase := usecase.NewWebhookUseCase(webhookRepo, txManager, webhook.NewClient(cfg.Webhook.Timeout, cfg.Webhook.AllowPrivateTargets), usecase.WebhookPolicy{
+		MaxAttempts:       cfg.Webhook.MaxAttempts,
+		RetryInitialDelay: cfg.Webhook.RetryInitialDelay,
+		RetryMaxDelay:     cfg.Webhook.RetryMaxDelay,
+		DisableAfter:      cfg.Webhook.DisableAfter,
+		SendTimeout:       cfg.Webhook.Timeout,
+	})
+
+	// Keep the local user projection in sync with the auth service
+	if err := rabbitmq.DeclareExchange(cfg.RabbitMQ.UserEventsExchange, "topic"); err != nil {
+		log.Fatalf("Failed to declare exchange: %v", err)
+	}
+	if err := rabbitmq.DeclareQueue(cfg.RabbitMQ.UserEventsQueue); err != nil {
+		log.Fatalf("Failed to declare queue: %v", err)
+	}
+	if err := rabbitmq.BindQueue(cfg.RabbitMQ.UserEventsQueue, cfg.RabbitMQ.UserEventsExchange, "user.*"); err != nil {
+		log.Fatalf("Failed to bind queue: %v", err)
+	}
+	userEventConsumer := deliverymq.NewUserEventConsumer(userUseCase)
+	userEventOptions := messaging.ConsumerOptions{OrderingKey: userEventConsumer.OrderingKey}
+	if err := rabbitmq.ConsumeWithOptions(cfg.RabbitMQ.UserEventsQueue, userEventConsumer.Handle, userEventOptions); err != nil {
+		log.Fatalf("Failed to consume user events: %v", err)
+	}
+
+	// Feed this instance's live subscribers from a per-instance queue
+	hub := stream.NewHub()
+	liveQueue := fmt.Sprintf("%s.%s", cfg.Stream.LiveQueuePrefix, uuid.New().String())
+	if err := rabbitmq.DeclareTransientQueue(liveQueue); err != nil {
+		log.Fatalf("Failed to declare queue: %v", err)
+	}
+	if err := rabbitmq.BindQueue(liveQueue, cfg.RabbitMQ.TransactionExchange, "transaction.#"); err != nil {
+		log.Fatalf("Failed to bind queue: %v", err)
+	}
+	transactionEventConsumer := deliverymq.NewTransactionEventConsumer(hub)
+	liveOptions := messaging.ConsumerOptions{Workers: 1, Transient: true}
+	if err := rabbitmq.ConsumeWithOptions(liveQueue, transactionEventConsumer.Handle, liveOptions); err != nil {
+		log.Fatalf("Failed to consume transaction events: %v", err)
+	}
+	// Queue webhook deliveries for transaction events
+	if err := rabbitmq.DeclareQueue(cfg.Webhook.Queue); err != nil {
+		log.Fatalf("Failed to declare queue: %v", err)
+	}
+	if err := rabbitmq.BindQueue(cfg.Webhook.Queue, cfg.RabbitMQ.TransactionExchange, "transaction.#"); err != nil {
+		log.Fatalf("Failed to bind queue: %v", err)
+	}
+	webhookEventConsumer := deliverymq.NewWebhookEventConsumer(webhookUseCase)
+	if err := rabbitmq.Consume(cfg.Webhook.Queue, webhookEventConsumer.Handle); err != nil {
+		log.Fatalf("Failed to consume webhook events: %v", err)
+	}
+
+	streamUseCase := usecase.NewStreamUseCase(hub, eventLogRepo, transactionRepo, cfg.Stream.BufferSize)
+
+	// Start background workers
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	outboxRelay := worker.NewOutboxRelay(outboxRepo, txManager, rabbitmq, cfg.Outbox.PollInterval, cfg.Outbox.BatchSize)
+	go outboxRelay.Run(ctx)
+
+	changeFeedListener := worker.NewChangeFeedListener(cfg.Database.GetDSN(), changeFeedUseCase, cfg.ChangeFeed.PollInterval, cfg.ChangeFeed.BatchSize)
+	go changeFeedListener.Run(ctx)
+
+	webhookDispatcher := worker.NewWebhookDispatcher(webhookUseCase, cfg.Webhook.PollInterval, cfg.Webhook.BatchSize)
+	go webhookDispatcher.Run(ctx)
+
+	idempotencyKeySweeper := worker.NewIdempotencyKeySweeper(idempotencyKeyRepo, cfg.Idempotency.SweepInterval, cfg.Idempotency.SweepBatch)
+	go idempotencyKeySweeper.Run(ctx)
+
+	pendingTransactionExpirer := worker.NewPendingTransactionExpirer(transactionUseCase, cfg.Expiry.Interval, cfg.Expiry.BatchSize)
+	go pendingTransactionExpirer.Run(ctx)
+
+	scheduler := worker.NewScheduler(scheduleUseCase, cfg.Scheduler.PollInterval, cfg.Scheduler.BatchSize)
+	go scheduler.Run(ctx)
 
 	// Initialize handlers
 	transactionHandler := handler.NewTransactionHandler(transactionUseCase)
+	deadLetterHandler := handler.NewDeadLetterHandler(deadLetterUseCase)
+	streamHandler := handler.NewStreamHandler(streamUseCase, cfg.Stream.HeartbeatInterval)
+	webSocketHandler := handler.NewWebSocketHandler(streamUseCase, cfg.Stream.HeartbeatInterval)
+	webhookHandler := handler.NewWebhookHandler(webhookUseCase)
+	walletHandler := handler.NewWalletHandler(walletUseCase)
+	fxRateHandler := handler.NewFXRateHandler(fxUseCase)
+	scheduleHandler := handler.NewScheduleHandler(scheduleUseCase)
 
 	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware(cfg.JWT.Secret)
+	authMiddleware := middleware.NewAuthMiddleware(cfg.JWT.Secret, cfg.JWT.AdminEmails)
 
 	// Setup router
-	r := router.SetupRouter(transactionHandler, authMiddleware)
+	r := router.SetupRouter(transactionHandler, streamHandler, webSocketHandler, webhookHandler, walletHandler, fxRateHandler, scheduleHandler, deadLetterHandler, authMiddleware)
 
 	// Start server
 	addr := fmt.Sprintf(":%s", cfg.Server.Port)
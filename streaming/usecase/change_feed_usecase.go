@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/domain/repository"
+)
+
+// ChangeFeedUseCase turns transaction rows changed outside the service into
+// the same events the transaction use case emits.
+type ChangeFeedUseCase interface {
+	// PublishPendingChanges emits events for up to limit captured changes and
+	// returns how many were handled.
+	PublishPendingChanges(ctx context.Context, limit int) (int, error)
+}
+
+type changeFeedUseCase struct {
+	repo           repository.TransactionRepository
+	historyRepo    repository.TransactionHistoryRepository
+	changeFeedRepo repository.ChangeFeedRepository
+	publisher      TransactionEventPublisher
+	txManager      repository.TxManager
+}
+
+func NewChangeFeedUseCase(
+	repo repository.TransactionRepository,
+	historyRepo repository.TransactionHistoryRepository,
+	changeFeedRepo repository.ChangeFeedRepository,
+	publisher TransactionEventPublisher,
+	txManager repository.TxManager,
+) ChangeFeedUseCase {
+	return &changeFeedUseCase{
+		repo:           repo,
+		historyRepo:    historyRepo,
+		changeFeedRepo: changeFeedRepo,
+		publisher:      publisher,
+		txManager:      txManager,
+	}
+}
+
+func (u *changeFeedUseCase) PublishPendingChanges(ctx context.Context, limit int) (int, error) {
+	handled := 0
+	for handled < limit {
+		ok, err := u.publishNextChange(ctx)
+		if err != nil {
+			return handled, err
+		}
+		if !ok {
+			break
+		}
+		handled++
+	}
+
+	return handled, nil
+}
+
+// publishNextChange handles the oldest pending change and reports whether
+// there was one. Each change is handled in its own database transaction:
+// publishing appends to the owner's event log, and appending for several
+// users in one database transaction could deadlock with one appending for
+// the same users in another order.
+func (u *changeFeedUseCase) publishNextChange(ctx context.Context) (bool, error) {
+	found := false
+	err := u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		changes, err := u.changeFeedRepo.LockPending(ctx, 1)
+		if err != nil || len(changes) == 0 {
+			return err
+		}
+		found = true
+
+		if err := u.publishChange(ctx, changes[0]); err != nil {
+			return err
+		}
+		return u.changeFeedRepo.MarkProcessed(ctx, changes[0].ID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// publishChange emits the event for one change, describing the transaction
+// as it is now. Changes to rows deleted since are skipped.
+func (u *changeFeedUseCase) publishChange(ctx context.Context, change *entity.TransactionChange) error {
+	transaction, err := u.repo.GetByID(ctx, change.TransactionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTransactionNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var event events.Event
+	switch change.Operation {
+	case entity.TransactionChangeInsert:
+		event = events.TransactionCreated{
+			Transaction: events.NewTransaction(transaction),
+		}
+	case entity.TransactionChangeUpdate:
+		previousStatus := ""
+		if change.PreviousStatus != nil {
+			previousStatus = *change.PreviousStatus
+		}
+		event = events.TransactionUpdated{
+			Transaction:    events.NewTransaction(transaction),
+			PreviousStatus: previousStatus,
+		}
+	default:
+		return fmt.Errorf("unknown transaction change operation: %s", change.Operation)
+	}
+
+	if err := u.recordStatusChange(ctx, change, transaction); err != nil {
+		return err
+	}
+
+	return u.publisher.Publish(ctx, transaction.UserID, event)
+}
+
+// recordStatusChange adds a change that created the transaction or moved its
+// status to the transaction's history. No actor or request is known for
+// changes made outside the service.
+func (u *changeFeedUseCase) recordStatusChange(ctx context.Context, change *entity.TransactionChange, transaction *entity.Transaction) error {
+	if change.Operation == entity.TransactionChangeUpdate &&
+		change.PreviousStatus != nil && *change.PreviousStatus == transaction.Status {
+		return nil
+	}
+
+	return u.historyRepo.Append(ctx, &entity.TransactionStatusChange{
+		TransactionID:  transaction.ID,
+		PreviousStatus: change.PreviousStatus,
+		Status:         transaction.Status,
+		Reason:         transaction.StatusReason,
+		CreatedAt:      change.CreatedAt,
+	})
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/domain/money"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPublishPendingChanges(t *testing.T) {
+	db := &memDB{}
+	u := NewChangeFeedUseCase(memTransactionRepo{db}, memHistoryRepo{db}, memChangeFeedRepo{db}, memPublisher{db}, db)
+
+	now := time.Now()
+	transaction := func(status string) *entity.Transaction {
+		transaction := &entity.Transaction{
+			ID:              uuid.New(),
+			UserID:          db.addUser(),
+			Amount:          money.MustParse("5"),
+			Currency:        "USD",
+			TransactionType: entity.TransactionTypeDeposit,
+			Status:          status,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		db.addTransaction(transaction)
+		return transaction
+	}
+	pending := entity.TransactionStatusPending
+	inserted := transaction(entity.TransactionStatusPending)
+	settled := transaction(entity.TransactionStatusSuccess)
+	renamed := transaction(entity.TransactionStatusPending)
+	db.changes = []entity.TransactionChange{
+		{ID: 1, TransactionID: inserted.ID, Operation: entity.TransactionChangeInsert, CreatedAt: now},
+		{ID: 2, TransactionID: settled.ID, Operation: entity.TransactionChangeUpdate, PreviousStatus: &pending, CreatedAt: now},
+		// A row deleted since its change was captured.
+		{ID: 3, TransactionID: uuid.New(), Operation: entity.TransactionChangeInsert, CreatedAt: now},
+		// An update that left the status alone, such as a new description.
+		{ID: 4, TransactionID: renamed.ID, Operation: entity.TransactionChangeUpdate, PreviousStatus: &pending, CreatedAt: now},
+	}
+
+	handled, err := u.PublishPendingChanges(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("PublishPendingChanges error = %v", err)
+	}
+	if handled != 4 {
+		t.Errorf("PublishPendingChanges = %d, want 4", handled)
+	}
+	for _, change := range db.changes {
+		if change.ProcessedAt == nil {
+			t.Errorf("change %d is not marked processed", change.ID)
+		}
+	}
+
+	wantEvents := []struct {
+		userID         uuid.UUID
+		eventType      string
+		previousStatus string
+	}{
+		{userID: inserted.UserID, eventType: events.TypeTransactionCreated},
+		{userID: settled.UserID, eventType: events.TypeTransactionUpdated, previousStatus: pending},
+		{userID: renamed.UserID, eventType: events.TypeTransactionUpdated, previousStatus: pending},
+	}
+	if len(db.published) != len(wantEvents) {
+		t.Fatalf("published %d events, want %d", len(db.published), len(wantEvents))
+	}
+	for i, want := range wantEvents {
+		published := db.published[i]
+		if published.UserID != want.userID || published.Event.EventType() != want.eventType {
+			t.Errorf("event %d = %s for %s, want %s for %s", i, published.Event.EventType(), published.UserID, want.eventType, want.userID)
+		}
+		if updated, ok := published.Event.(events.TransactionUpdated); ok && updated.PreviousStatus != want.previousStatus {
+			t.Errorf("event %d previous status = %s, want %s", i, updated.PreviousStatus, want.previousStatus)
+		}
+	}
+
+	// Only changes that created a transaction or moved its status reach
+	// its history.
+	historyRows := map[uuid.UUID]int{inserted.ID: 1, settled.ID: 1, renamed.ID: 0}
+	for id, want := range historyRows {
+		if got := len(db.historyOf(id)); got != want {
+			t.Errorf("transaction %s has %d history rows, want %d", id, got, want)
+		}
+	}
+
+	// Each change commits on its own, so no database transaction appends
+	// events for more than one user.
+	for i, published := range db.commits {
+		if published > 1 {
+			t.Errorf("database transaction %d published %d events, want at most 1", i, published)
+		}
+	}
+}
+
+func TestPublishPendingChangesLimit(t *testing.T) {
+	db := &memDB{}
+	u := NewChangeFeedUseCase(memTransactionRepo{db}, memHistoryRepo{db}, memChangeFeedRepo{db}, memPublisher{db}, db)
+
+	for id := int64(1); id <= 3; id++ {
+		db.changes = append(db.changes, entity.TransactionChange{ID: id, TransactionID: uuid.New(), Operation: entity.TransactionChangeInsert})
+	}
+
+	handled, err := u.PublishPendingChanges(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("PublishPendingChanges error = %v", err)
+	}
+	if handled != 2 {
+		t.Errorf("PublishPendingChanges = %d, want 2", handled)
+	}
+	if db.changes[2].ProcessedAt != nil {
+		t.Errorf("change 3 is processed, want it left for the next batch")
+	}
+}
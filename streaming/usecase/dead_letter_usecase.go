@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"go-api-streaming/infrastructure/messaging"
+)
+
+// DeadLetterUseCase lets operators inspect and re-drive messages that
+// exhausted their retries.
+type DeadLetterUseCase interface {
+	ListDeadLetters(queueName string, limit int) ([]messaging.DeadLetter, error)
+	RedriveDeadLetters(queueName string, limit int) (int, error)
+}
+
+type deadLetterUseCase struct {
+	rabbitmq *messaging.RabbitMQClient
+}
+
+func NewDeadLetterUseCase(rabbitmq *messaging.RabbitMQClient) DeadLetterUseCase {
+	return &deadLetterUseCase{
+		rabbitmq: rabbitmq,
+	}
+}
+
+func (u *deadLetterUseCase) ListDeadLetters(queueName string, limit int) ([]messaging.DeadLetter, error) {
+	return u.rabbitmq.ListDeadLetters(queueName, clampLimit(limit))
+}
+
+func (u *deadLetterUseCase) RedriveDeadLetters(queueName string, limit int) (int, error) {
+	return u.rabbitmq.RedriveDeadLetters(queueName, clampLimit(limit))
+}
+
+func clampLimit(limit int) int {
+	if limit < 1 || limit > 100 {
+		return 10
+	}
+	return limit
+}
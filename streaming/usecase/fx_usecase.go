@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/money"
+	"go-api-streaming/domain/repository"
+	"time"
+)
+
+// ErrInvalidFXRate is wrapped by validation errors of exchange rates.
+var ErrInvalidFXRate = errors.New("invalid exchange rate")
+
+// ErrNoExchangeRate is returned when two currencies cannot be converted
+// because no rate between them is in effect.
+var ErrNoExchangeRate = errors.New("no exchange rate")
+
+// FXUseCase manages exchange rates and converts between currencies.
+type FXUseCase interface {
+	// LoadRates stores rates in one database transaction. A rate for a pair
+	// and effective time that already exists is replaced.
+	LoadRates(ctx context.Context, reqs []*LoadFXRateRequest) ([]*entity.FXRate, error)
+	ListRates(ctx context.Context, base, quote string, limit int) ([]*entity.FXRate, error)
+	// Rate returns the rate converting from into to at the given time. When
+	// only the opposite pair has a rate, its inverse is used.
+	Rate(ctx context.Context, from, to string, at time.Time) (money.Rate, error)
+}
+
+type fxUseCase struct {
+	repo      repository.FXRateRepository
+	txManager repository.TxManager
+}
+
+// LoadFXRateRequest is one rate to load. EffectiveAt defaults to now.
+type LoadFXRateRequest struct {
+	BaseCurrency  string     `json:"base_currency"`
+	QuoteCurrency string     `json:"quote_currency"`
+	Rate          money.Rate `json:"rate"`
+	EffectiveAt   *time.Time `json:"effective_at,omitempty"`
+}
+
+func NewFXUseCase(repo repository.FXRateRepository, txManager repository.TxManager) FXUseCase {
+	return &fxUseCase{
+		repo:      repo,
+		txManager: txManager,
+	}
+}
+
+func (u *fxUseCase) LoadRates(ctx context.Context, reqs []*LoadFXRateRequest) ([]*entity.FXRate, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("%w: no rates given", ErrInvalidFXRate)
+	}
+
+	now := time.Now()
+	rates := make([]*entity.FXRate, 0, len(reqs))
+	for i, req := range reqs {
+		if !money.IsCurrency(req.BaseCurrency) || !money.IsCurrency(req.QuoteCurrency) {
+			return nil, fmt.Errorf("%w: rate %d: currencies must be ISO 4217 codes", ErrInvalidFXRate, i)
+		}
+		if req.BaseCurrency == req.QuoteCurrency {
+			return nil, fmt.Errorf("%w: rate %d: base and quote currency are the same", ErrInvalidFXRate, i)
+		}
+		if !req.Rate.IsPositive() {
+			return nil, fmt.Errorf("%w: rate %d: rate is required", ErrInvalidFXRate, i)
+		}
+
+		effectiveAt := now
+		if req.EffectiveAt != nil {
+			effectiveAt = *req.EffectiveAt
+		}
+
+		rates = append(rates, &entity.FXRate{
+			BaseCurrency:  req.BaseCurrency,
+			QuoteCurrency: req.QuoteCurrency,
+			Rate:          req.Rate,
+			EffectiveAt:   effectiveAt,
+			CreatedAt:     now,
+		})
+	}
+
+	err := u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		return u.repo.Save(ctx, rates)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func (u *fxUseCase) ListRates(ctx context.Context, base, quote string, limit int) ([]*entity.FXRate, error) {
+	if limit < 1 || limit > 100 {
+		limit = 100
+	}
+
+	return u.repo.List(ctx, base, quote, limit)
+}
+
+func (u *fxUseCase) Rate(ctx context.Context, from, to string, at time.Time) (money.Rate, error) {
+	if from == to {
+		return money.RateOne, nil
+	}
+
+	rate, err := u.repo.GetEffective(ctx, from, to, at)
+	if err == nil {
+		return rate.Rate, nil
+	}
+	if !errors.Is(err, repository.ErrFXRateNotFound) {
+		return money.Rate{}, err
+	}
+
+	inverse, err := u.repo.GetEffective(ctx, to, from, at)
+	if errors.Is(err, repository.ErrFXRateNotFound) {
+		return money.Rate{}, fmt.Errorf("%w from %s to %s", ErrNoExchangeRate, from, to)
+	}
+	if err != nil {
+		return money.Rate{}, err
+	}
+
+	return inverse.Rate.Invert()
+}
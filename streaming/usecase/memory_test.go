@@ -0,0 +1,580 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/domain/money"
+	"go-api-streaming/domain/repository"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memDB is an in-memory stand-in for the database behind the repositories
+// the use cases depend on. WithinTransaction rolls every table back when fn
+// fails, and nested calls join the outer transaction as they do in
+// txManagerImpl.
+type memDB struct {
+	transactions []entity.Transaction
+	history      []entity.TransactionStatusChange
+	accounts     []entity.LedgerAccount
+	entries      []entity.LedgerEntry
+	keys         []entity.IdempotencyKey
+	users        []entity.UserProjection
+	rates        []entity.FXRate
+	changes      []entity.TransactionChange
+	published    []publishedEvent
+
+	// commits holds, for each outermost transaction that committed, the
+	// number of events it published.
+	commits []int
+}
+
+// publishedEvent is an event passed to memPublisher.
+type publishedEvent struct {
+	UserID uuid.UUID
+	Event  events.Event
+}
+
+var (
+	_ repository.TxManager                    = (*memDB)(nil)
+	_ repository.TransactionRepository        = memTransactionRepo{}
+	_ repository.TransactionHistoryRepository = memHistoryRepo{}
+	_ repository.LedgerRepository             = memLedgerRepo{}
+	_ repository.IdempotencyKeyRepository     = memKeyRepo{}
+	_ repository.UserProjectionRepository     = memUserRepo{}
+	_ repository.FXRateRepository             = memFXRateRepo{}
+	_ repository.ChangeFeedRepository         = memChangeFeedRepo{}
+	_ TransactionEventPublisher               = memPublisher{}
+)
+
+type memTxKey struct{}
+
+func (db *memDB) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(memTxKey{}) != nil {
+		return fn(ctx)
+	}
+
+	saved := db.snapshot()
+	if err := fn(context.WithValue(ctx, memTxKey{}, true)); err != nil {
+		*db = saved
+		return err
+	}
+	db.commits = append(db.commits, len(db.published)-len(saved.published))
+
+	return nil
+}
+
+func (db *memDB) snapshot() memDB {
+	return memDB{
+		transactions: append([]entity.Transaction(nil), db.transactions...),
+		history:      append([]entity.TransactionStatusChange(nil), db.history...),
+		accounts:     append([]entity.LedgerAccount(nil), db.accounts...),
+		entries:      append([]entity.LedgerEntry(nil), db.entries...),
+		keys:         append([]entity.IdempotencyKey(nil), db.keys...),
+		users:        append([]entity.UserProjection(nil), db.users...),
+		rates:        append([]entity.FXRate(nil), db.rates...),
+		changes:      append([]entity.TransactionChange(nil), db.changes...),
+		published:    append([]publishedEvent(nil), db.published...),
+		commits:      append([]int(nil), db.commits...),
+	}
+}
+
+// addUser adds a user the use cases accept as existing.
+func (db *memDB) addUser() uuid.UUID {
+	id := uuid.New()
+	db.users = append(db.users, entity.UserProjection{ID: id, LastEventAt: time.Now()})
+	return id
+}
+
+// addTransaction stores a copy of transaction as if it had been created
+// earlier.
+func (db *memDB) addTransaction(transaction *entity.Transaction) {
+	db.transactions = append(db.transactions, *transaction)
+}
+
+// transaction returns the stored copy of a transaction, or nil.
+func (db *memDB) transaction(id uuid.UUID) *entity.Transaction {
+	for i := range db.transactions {
+		if db.transactions[i].ID == id {
+			transaction := db.transactions[i]
+			return &transaction
+		}
+	}
+	return nil
+}
+
+// deposit credits a user's wallet from the external account, as a settled
+// deposit would.
+func (db *memDB) deposit(userID uuid.UUID, amount money.Amount, currency string) {
+	ledger := memLedgerRepo{db}
+	ctx := context.Background()
+	wallet, _ := ledger.LockWallet(ctx, userID, currency)
+	external, _ := ledger.GetSystemAccount(ctx, entity.LedgerAccountExternal, currency)
+	id := uuid.New()
+	ledger.CreateEntries(ctx, []*entity.LedgerEntry{
+		{TransactionID: id, AccountID: external.ID, Direction: entity.LedgerDebit, Amount: amount, Currency: currency},
+		{TransactionID: id, AccountID: wallet.ID, Direction: entity.LedgerCredit, Amount: amount, Currency: currency},
+	})
+}
+
+// balance returns the balance of a user's wallet.
+func (db *memDB) balance(userID uuid.UUID, currency string) money.Amount {
+	for _, account := range db.accounts {
+		if account.UserID != nil && *account.UserID == userID && account.Currency == currency {
+			balance, _ := memLedgerRepo{db}.Balance(context.Background(), account.ID)
+			return balance
+		}
+	}
+	return money.Zero
+}
+
+// historyOf returns the status history of a transaction, oldest first.
+func (db *memDB) historyOf(transactionID uuid.UUID) []entity.TransactionStatusChange {
+	var history []entity.TransactionStatusChange
+	for _, change := range db.history {
+		if change.TransactionID == transactionID {
+			history = append(history, change)
+		}
+	}
+	return history
+}
+
+// newTestTransactionUseCase wires a transaction use case to db.
+func newTestTransactionUseCase(db *memDB) *transactionUseCase {
+	return NewTransactionUseCase(
+		memTransactionRepo{db},
+		memHistoryRepo{db},
+		memPublisher{db},
+		NewWalletUseCase(memLedgerRepo{db}),
+		NewFXUseCase(memFXRateRepo{db}, db),
+		memUserRepo{db},
+		memKeyRepo{db},
+		db,
+		24*time.Hour,
+		PendingExpiryPolicy{},
+	).(*transactionUseCase)
+}
+
+type memTransactionRepo struct{ db *memDB }
+
+func (r memTransactionRepo) Create(ctx context.Context, transaction *entity.Transaction) error {
+	r.db.transactions = append(r.db.transactions, *transaction)
+	return nil
+}
+
+func (r memTransactionRepo) GetByID(ctx context.Context, id uuid.UUID) (*entity.Transaction, error) {
+	if transaction := r.db.transaction(id); transaction != nil {
+		return transaction, nil
+	}
+	return nil, repository.ErrTransactionNotFound
+}
+
+func (r memTransactionRepo) LockByID(ctx context.Context, id uuid.UUID) (*entity.Transaction, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r memTransactionRepo) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*entity.Transaction, error) {
+	return r.page(func(t *entity.Transaction) bool { return t.UserID == userID }, limit, offset), nil
+}
+
+func (r memTransactionRepo) GetByParentID(ctx context.Context, parentID uuid.UUID) ([]*entity.Transaction, error) {
+	var refunds []*entity.Transaction
+	for i := range r.db.transactions {
+		if parent := r.db.transactions[i].ParentTransactionID; parent != nil && *parent == parentID {
+			refund := r.db.transactions[i]
+			refunds = append(refunds, &refund)
+		}
+	}
+	return refunds, nil
+}
+
+func (r memTransactionRepo) Update(ctx context.Context, transaction *entity.Transaction) error {
+	for i := range r.db.transactions {
+		if r.db.transactions[i].ID == transaction.ID {
+			r.db.transactions[i] = *transaction
+			return nil
+		}
+	}
+	return repository.ErrTransactionNotFound
+}
+
+func (r memTransactionRepo) GetAll(ctx context.Context, limit, offset int) ([]*entity.Transaction, error) {
+	return r.page(func(*entity.Transaction) bool { return true }, limit, offset), nil
+}
+
+func (r memTransactionRepo) GetByStatus(ctx context.Context, status string, limit, offset int) ([]*entity.Transaction, error) {
+	return r.page(func(t *entity.Transaction) bool { return t.Status == status }, limit, offset), nil
+}
+
+func (r memTransactionRepo) UserExists(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (r memTransactionRepo) LockExpiredPending(ctx context.Context, transactionType string, createdBefore time.Time, limit int) ([]*entity.Transaction, error) {
+	var expired []*entity.Transaction
+	for i := range r.db.transactions {
+		transaction := r.db.transactions[i]
+		if transaction.Status == entity.TransactionStatusPending &&
+			transaction.TransactionType == transactionType &&
+			transaction.CreatedAt.Before(createdBefore) {
+			expired = append(expired, &transaction)
+		}
+	}
+	sort.SliceStable(expired, func(i, j int) bool { return expired[i].CreatedAt.Before(expired[j].CreatedAt) })
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	return expired, nil
+}
+
+func (r memTransactionRepo) Stream(ctx context.Context, filter repository.TransactionFilter, fn func(*entity.Transaction) error) error {
+	for _, transaction := range r.page(filterMatcher(filter), len(r.db.transactions), 0) {
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r memTransactionRepo) Summarize(ctx context.Context, filter repository.TransactionFilter) ([]*entity.TransactionTotal, error) {
+	var totals []*entity.TransactionTotal
+	for _, transaction := range r.page(filterMatcher(filter), len(r.db.transactions), 0) {
+		var total *entity.TransactionTotal
+		for _, t := range totals {
+			if t.TransactionType == transaction.TransactionType && t.Currency == transaction.Currency {
+				total = t
+			}
+		}
+		if total == nil {
+			total = &entity.TransactionTotal{TransactionType: transaction.TransactionType, Currency: transaction.Currency}
+			totals = append(totals, total)
+		}
+		total.Count++
+		var err error
+		if total.Amount, err = total.Amount.Add(transaction.Amount); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].TransactionType != totals[j].TransactionType {
+			return totals[i].TransactionType < totals[j].TransactionType
+		}
+		return totals[i].Currency < totals[j].Currency
+	})
+	return totals, nil
+}
+
+// page returns the transactions matching match, newest first.
+func (r memTransactionRepo) page(match func(*entity.Transaction) bool, limit, offset int) []*entity.Transaction {
+	var matched []*entity.Transaction
+	for i := len(r.db.transactions) - 1; i >= 0; i-- {
+		transaction := r.db.transactions[i]
+		if match(&transaction) {
+			matched = append(matched, &transaction)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].CreatedAt.After(matched[j].CreatedAt) })
+	if offset >= len(matched) {
+		return nil
+	}
+	matched = matched[offset:]
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched
+}
+
+func filterMatcher(filter repository.TransactionFilter) func(*entity.Transaction) bool {
+	return func(t *entity.Transaction) bool {
+		return (filter.UserID == uuid.Nil || t.UserID == filter.UserID) &&
+			(filter.Status == "" || t.Status == filter.Status)
+	}
+}
+
+type memHistoryRepo struct{ db *memDB }
+
+func (r memHistoryRepo) Append(ctx context.Context, change *entity.TransactionStatusChange) error {
+	change.ID = int64(len(r.db.history) + 1)
+	r.db.history = append(r.db.history, *change)
+	return nil
+}
+
+func (r memHistoryRepo) ListByTransactionID(ctx context.Context, transactionID uuid.UUID) ([]*entity.TransactionStatusChange, error) {
+	var history []*entity.TransactionStatusChange
+	for _, change := range r.db.historyOf(transactionID) {
+		change := change
+		history = append(history, &change)
+	}
+	return history, nil
+}
+
+type memLedgerRepo struct{ db *memDB }
+
+func (r memLedgerRepo) LockWallet(ctx context.Context, userID uuid.UUID, currency string) (*entity.LedgerAccount, error) {
+	return r.account(&userID, entity.LedgerAccountWallet, currency), nil
+}
+
+func (r memLedgerRepo) GetSystemAccount(ctx context.Context, name, currency string) (*entity.LedgerAccount, error) {
+	return r.account(nil, name, currency), nil
+}
+
+func (r memLedgerRepo) account(userID *uuid.UUID, name, currency string) *entity.LedgerAccount {
+	for i := range r.db.accounts {
+		account := r.db.accounts[i]
+		sameUser := (account.UserID == nil && userID == nil) ||
+			(account.UserID != nil && userID != nil && *account.UserID == *userID)
+		if sameUser && account.Name == name && account.Currency == currency {
+			return &account
+		}
+	}
+
+	account := entity.LedgerAccount{ID: uuid.New(), UserID: userID, Name: name, Currency: currency}
+	r.db.accounts = append(r.db.accounts, account)
+	return &account
+}
+
+func (r memLedgerRepo) Balance(ctx context.Context, accountID uuid.UUID) (money.Amount, error) {
+	balance := money.Zero
+	for _, entry := range r.db.entries {
+		if entry.AccountID != accountID {
+			continue
+		}
+		var err error
+		if entry.Direction == entity.LedgerCredit {
+			balance, err = balance.Add(entry.Amount)
+		} else {
+			balance, err = balance.Sub(entry.Amount)
+		}
+		if err != nil {
+			return money.Zero, err
+		}
+	}
+	return balance, nil
+}
+
+func (r memLedgerRepo) ReservedAmount(ctx context.Context, userID uuid.UUID, currency string) (money.Amount, error) {
+	reserved := money.Zero
+	for _, transaction := range r.db.transactions {
+		if transaction.UserID != userID || transaction.Currency != currency {
+			continue
+		}
+		switch transaction.Status {
+		case entity.TransactionStatusPending, entity.TransactionStatusProcessing:
+		default:
+			continue
+		}
+		switch transaction.TransactionType {
+		case entity.TransactionTypeWithdraw, entity.TransactionTypePurchase, entity.TransactionTypeReversal:
+		default:
+			continue
+		}
+		var err error
+		if reserved, err = reserved.Add(transaction.Amount); err != nil {
+			return money.Zero, err
+		}
+	}
+	return reserved, nil
+}
+
+func (r memLedgerRepo) HasEntries(ctx context.Context, transactionID uuid.UUID) (bool, error) {
+	entries, err := r.ListEntries(ctx, transactionID)
+	return len(entries) > 0, err
+}
+
+func (r memLedgerRepo) ListEntries(ctx context.Context, transactionID uuid.UUID) ([]*entity.LedgerEntry, error) {
+	var entries []*entity.LedgerEntry
+	for _, entry := range r.db.entries {
+		if entry.TransactionID == transactionID {
+			entry := entry
+			entries = append(entries, &entry)
+		}
+	}
+	return entries, nil
+}
+
+func (r memLedgerRepo) CreateEntries(ctx context.Context, entries []*entity.LedgerEntry) error {
+	for _, entry := range entries {
+		entry.ID = int64(len(r.db.entries) + 1)
+		r.db.entries = append(r.db.entries, *entry)
+	}
+	return nil
+}
+
+func (r memLedgerRepo) ListWallets(ctx context.Context, userID uuid.UUID) ([]*entity.Wallet, error) {
+	var wallets []*entity.Wallet
+	for _, account := range r.db.accounts {
+		if account.UserID == nil || *account.UserID != userID {
+			continue
+		}
+		balance, err := r.Balance(ctx, account.ID)
+		if err != nil {
+			return nil, err
+		}
+		reserved, err := r.ReservedAmount(ctx, userID, account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, &entity.Wallet{Currency: account.Currency, Balance: balance, Reserved: reserved})
+	}
+	return wallets, nil
+}
+
+type memKeyRepo struct{ db *memDB }
+
+func (r memKeyRepo) Claim(ctx context.Context, key *entity.IdempotencyKey) (bool, error) {
+	for i, existing := range r.db.keys {
+		if existing.UserID != key.UserID || existing.Key != key.Key {
+			continue
+		}
+		if existing.ExpiresAt.After(key.CreatedAt) {
+			return false, nil
+		}
+		r.db.keys[i] = *key
+		return true, nil
+	}
+
+	r.db.keys = append(r.db.keys, *key)
+	return true, nil
+}
+
+func (r memKeyRepo) Get(ctx context.Context, userID uuid.UUID, key string) (*entity.IdempotencyKey, error) {
+	for _, existing := range r.db.keys {
+		if existing.UserID == userID && existing.Key == key {
+			return &existing, nil
+		}
+	}
+	return nil, errors.New("idempotency key not found")
+}
+
+func (r memKeyRepo) SaveResponse(ctx context.Context, userID uuid.UUID, key string, response json.RawMessage) error {
+	for i := range r.db.keys {
+		if r.db.keys[i].UserID == userID && r.db.keys[i].Key == key {
+			r.db.keys[i].Response = response
+			return nil
+		}
+	}
+	return errors.New("idempotency key not found")
+}
+
+func (r memKeyRepo) DeleteExpired(ctx context.Context, now time.Time, limit int) (int, error) {
+	kept := r.db.keys[:0]
+	deleted := 0
+	for _, key := range r.db.keys {
+		if deleted < limit && key.ExpiresAt.Before(now) {
+			deleted++
+			continue
+		}
+		kept = append(kept, key)
+	}
+	r.db.keys = kept
+	return deleted, nil
+}
+
+type memUserRepo struct{ db *memDB }
+
+func (r memUserRepo) Upsert(ctx context.Context, user *entity.UserProjection) error {
+	for i := range r.db.users {
+		if r.db.users[i].ID == user.ID {
+			if r.db.users[i].LastEventAt.After(user.LastEventAt) {
+				return nil
+			}
+			r.db.users[i] = *user
+			return nil
+		}
+	}
+	r.db.users = append(r.db.users, *user)
+	return nil
+}
+
+func (r memUserRepo) MarkDeleted(ctx context.Context, id uuid.UUID, eventAt time.Time) error {
+	for i := range r.db.users {
+		if r.db.users[i].ID == id && !r.db.users[i].LastEventAt.After(eventAt) {
+			r.db.users[i].Deleted = true
+			r.db.users[i].LastEventAt = eventAt
+		}
+	}
+	return nil
+}
+
+func (r memUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*entity.UserProjection, error) {
+	for _, user := range r.db.users {
+		if user.ID == id {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+type memFXRateRepo struct{ db *memDB }
+
+func (r memFXRateRepo) Save(ctx context.Context, rates []*entity.FXRate) error {
+	for _, rate := range rates {
+		r.db.rates = append(r.db.rates, *rate)
+	}
+	return nil
+}
+
+func (r memFXRateRepo) GetEffective(ctx context.Context, base, quote string, at time.Time) (*entity.FXRate, error) {
+	var effective *entity.FXRate
+	for _, rate := range r.db.rates {
+		if rate.BaseCurrency != base || rate.QuoteCurrency != quote || rate.EffectiveAt.After(at) {
+			continue
+		}
+		if effective == nil || rate.EffectiveAt.After(effective.EffectiveAt) {
+			rate := rate
+			effective = &rate
+		}
+	}
+	if effective == nil {
+		return nil, repository.ErrFXRateNotFound
+	}
+	return effective, nil
+}
+
+func (r memFXRateRepo) List(ctx context.Context, base, quote string, limit int) ([]*entity.FXRate, error) {
+	var rates []*entity.FXRate
+	for i := len(r.db.rates) - 1; i >= 0 && len(rates) < limit; i-- {
+		rate := r.db.rates[i]
+		if (base == "" || rate.BaseCurrency == base) && (quote == "" || rate.QuoteCurrency == quote) {
+			rates = append(rates, &rate)
+		}
+	}
+	return rates, nil
+}
+
+type memChangeFeedRepo struct{ db *memDB }
+
+func (r memChangeFeedRepo) LockPending(ctx context.Context, limit int) ([]*entity.TransactionChange, error) {
+	var changes []*entity.TransactionChange
+	for _, change := range r.db.changes {
+		if change.ProcessedAt == nil && len(changes) < limit {
+			change := change
+			changes = append(changes, &change)
+		}
+	}
+	return changes, nil
+}
+
+func (r memChangeFeedRepo) MarkProcessed(ctx context.Context, id int64) error {
+	now := time.Now()
+	for i := range r.db.changes {
+		if r.db.changes[i].ID == id {
+			r.db.changes[i].ProcessedAt = &now
+		}
+	}
+	return nil
+}
+
+// memPublisher records published events in db, so they are rolled back with
+// the database transaction that published them.
+type memPublisher struct{ db *memDB }
+
+func (p memPublisher) Publish(ctx context.Context, userID uuid.UUID, event events.Event) error {
+	p.db.published = append(p.db.published, publishedEvent{UserID: userID, Event: event})
+	return nil
+}
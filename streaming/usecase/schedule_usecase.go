@@ -0,0 +1,483 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/money"
+	"go-api-streaming/domain/recurrence"
+	"go-api-streaming/domain/repository"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidSchedule is wrapped by validation errors of schedule requests.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ErrScheduleStatus is wrapped by errors rejecting a change the schedule's
+// status does not allow, such as resuming a cancelled schedule.
+var ErrScheduleStatus = errors.New("schedule status does not allow this change")
+
+// Bounds of the delay before retrying an occurrence that failed for a
+// reason other than the transaction rules. The delay grows with how long the
+// occurrence has been due.
+const (
+	minScheduleRetryDelay = time.Minute
+	maxScheduleRetryDelay = time.Hour
+)
+
+// schedulableTransactionTypes are the transaction types a schedule may
+// create. Refunds and reversals need a parent and cannot be scheduled.
+var schedulableTransactionTypes = []string{
+	entity.TransactionTypeDeposit,
+	entity.TransactionTypeWithdraw,
+	entity.TransactionTypePurchase,
+}
+
+// ScheduleUseCase manages users' scheduled and recurring transactions and
+// creates the transactions of due occurrences.
+type ScheduleUseCase interface {
+	CreateSchedule(ctx context.Context, userID uuid.UUID, req *CreateScheduleRequest) (*entity.Schedule, error)
+	ListSchedules(ctx context.Context, userID uuid.UUID) ([]*entity.Schedule, error)
+	GetSchedule(ctx context.Context, id, userID uuid.UUID) (*entity.Schedule, error)
+	UpdateSchedule(ctx context.Context, id, userID uuid.UUID, req *UpdateScheduleRequest) (*entity.Schedule, error)
+	PauseSchedule(ctx context.Context, id, userID uuid.UUID) (*entity.Schedule, error)
+	// ResumeSchedule reactivates a paused schedule. Occurrences of a
+	// recurring schedule missed while it was paused are skipped.
+	ResumeSchedule(ctx context.Context, id, userID uuid.UUID) (*entity.Schedule, error)
+	CancelSchedule(ctx context.Context, id, userID uuid.UUID) (*entity.Schedule, error)
+
+	// RunDueSchedules creates the transactions of up to limit due
+	// occurrences and returns how many occurrences were handled. An
+	// occurrence that fails is recorded on its schedule and retried later,
+	// without holding up the other schedules.
+	RunDueSchedules(ctx context.Context, limit int) (int, error)
+}
+
+type scheduleUseCase struct {
+	repo         repository.ScheduleRepository
+	transactions TransactionUseCase
+	txManager    repository.TxManager
+}
+
+type CreateScheduleRequest struct {
+	Amount          money.Amount `json:"amount"`
+	Currency        string       `json:"currency"`
+	TransactionType string       `json:"transaction_type"`
+	Description     *string      `json:"description,omitempty"`
+	// Recurrence is an RRULE such as "FREQ=MONTHLY". Without one the
+	// schedule runs once.
+	Recurrence *string `json:"recurrence,omitempty"`
+	// StartAt defaults to now.
+	StartAt time.Time  `json:"start_at"`
+	EndAt   *time.Time `json:"end_at,omitempty"`
+}
+
+// UpdateScheduleRequest changes the fields that are set. The changes apply to
+// occurrences that have not run yet.
+type UpdateScheduleRequest struct {
+	Amount      *money.Amount `json:"amount"`
+	Description *string       `json:"description"`
+	EndAt       *time.Time    `json:"end_at"`
+}
+
+func NewScheduleUseCase(
+	repo repository.ScheduleRepository,
+	transactions TransactionUseCase,
+	txManager repository.TxManager,
+) ScheduleUseCase {
+	return &scheduleUseCase{
+		repo:         repo,
+		transactions: transactions,
+		txManager:    txManager,
+	}
+}
+
+func (u *scheduleUseCase) CreateSchedule(ctx context.Context, userID uuid.UUID, req *CreateScheduleRequest) (*entity.Schedule, error) {
+	if err := validateAmount(req.Amount, req.Currency); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if !containsValue(schedulableTransactionTypes, req.TransactionType) {
+		return nil, fmt.Errorf("%w: transaction type must be one of deposit, withdraw or purchase", ErrInvalidSchedule)
+	}
+
+	now := time.Now()
+	startAt := req.StartAt
+	if startAt.IsZero() {
+		startAt = now
+	}
+
+	var rule *string
+	nextRunAt := startAt
+	if req.Recurrence != nil {
+		parsed, err := recurrence.Parse(*req.Recurrence)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+		normalized := parsed.String()
+		rule = &normalized
+
+		first, ok := parsed.First(startAt)
+		if !ok {
+			return nil, fmt.Errorf("%w: recurrence never occurs", ErrInvalidSchedule)
+		}
+		nextRunAt = first
+	} else if req.EndAt != nil {
+		return nil, fmt.Errorf("%w: end_at is only allowed with a recurrence", ErrInvalidSchedule)
+	}
+	if req.EndAt != nil && req.EndAt.Before(startAt) {
+		return nil, fmt.Errorf("%w: end_at must not be before start_at", ErrInvalidSchedule)
+	}
+
+	schedule := &entity.Schedule{
+		ID:              uuid.New(),
+		UserID:          userID,
+		Amount:          req.Amount,
+		Currency:        req.Currency,
+		TransactionType: req.TransactionType,
+		Description:     req.Description,
+		Recurrence:      rule,
+		StartAt:         startAt,
+		EndAt:           req.EndAt,
+		Status:          entity.ScheduleStatusActive,
+		NextRunAt:       &nextRunAt,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	// A recurring schedule starting in the past begins at its first
+	// occurrence from now, rather than catching up on every occurrence
+	// before it at once.
+	if rule != nil {
+		if err := skipMissedOccurrences(schedule, now); err != nil {
+			return nil, err
+		}
+		if schedule.Status == entity.ScheduleStatusCompleted {
+			return nil, fmt.Errorf("%w: no occurrence is left before end_at", ErrInvalidSchedule)
+		}
+	}
+
+	if err := u.repo.Create(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (u *scheduleUseCase) ListSchedules(ctx context.Context, userID uuid.UUID) ([]*entity.Schedule, error) {
+	schedules, err := u.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if schedules == nil {
+		schedules = []*entity.Schedule{}
+	}
+
+	return schedules, nil
+}
+
+func (u *scheduleUseCase) GetSchedule(ctx context.Context, id, userID uuid.UUID) (*entity.Schedule, error) {
+	schedule, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Other users' schedules are reported as missing.
+	if schedule.UserID != userID {
+		return nil, repository.ErrScheduleNotFound
+	}
+
+	return schedule, nil
+}
+
+func (u *scheduleUseCase) UpdateSchedule(ctx context.Context, id, userID uuid.UUID, req *UpdateScheduleRequest) (*entity.Schedule, error) {
+	return u.change(ctx, id, userID, func(schedule *entity.Schedule) error {
+		if !isOpenSchedule(schedule.Status) {
+			return fmt.Errorf("%w: schedule is %s", ErrScheduleStatus, schedule.Status)
+		}
+
+		if req.Amount != nil {
+			if err := validateAmount(*req.Amount, schedule.Currency); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+			}
+			schedule.Amount = *req.Amount
+		}
+		if req.Description != nil {
+			schedule.Description = req.Description
+		}
+		// The change may fix what made the occurrence fail.
+		schedule.RetryAt = nil
+		if req.EndAt != nil {
+			if schedule.Recurrence == nil {
+				return fmt.Errorf("%w: end_at is only allowed with a recurrence", ErrInvalidSchedule)
+			}
+			if req.EndAt.Before(schedule.StartAt) {
+				return fmt.Errorf("%w: end_at must not be before start_at", ErrInvalidSchedule)
+			}
+			schedule.EndAt = req.EndAt
+
+			// An earlier end may leave no occurrence to run.
+			if schedule.NextRunAt != nil && schedule.NextRunAt.After(*req.EndAt) {
+				schedule.NextRunAt = nil
+				schedule.Status = entity.ScheduleStatusCompleted
+			}
+		}
+
+		return nil
+	})
+}
+
+func (u *scheduleUseCase) PauseSchedule(ctx context.Context, id, userID uuid.UUID) (*entity.Schedule, error) {
+	return u.change(ctx, id, userID, func(schedule *entity.Schedule) error {
+		if schedule.Status != entity.ScheduleStatusActive {
+			return fmt.Errorf("%w: only an active schedule can be paused, this one is %s", ErrScheduleStatus, schedule.Status)
+		}
+
+		schedule.Status = entity.ScheduleStatusPaused
+		return nil
+	})
+}
+
+func (u *scheduleUseCase) ResumeSchedule(ctx context.Context, id, userID uuid.UUID) (*entity.Schedule, error) {
+	return u.change(ctx, id, userID, func(schedule *entity.Schedule) error {
+		if schedule.Status != entity.ScheduleStatusPaused {
+			return fmt.Errorf("%w: only a paused schedule can be resumed, this one is %s", ErrScheduleStatus, schedule.Status)
+		}
+
+		schedule.Status = entity.ScheduleStatusActive
+		schedule.RetryAt = nil
+
+		// A one-off schedule keeps its only occurrence, which runs right
+		// away if it fell due while the schedule was paused.
+		if schedule.Recurrence == nil {
+			return nil
+		}
+
+		return skipMissedOccurrences(schedule, time.Now())
+	})
+}
+
+func (u *scheduleUseCase) CancelSchedule(ctx context.Context, id, userID uuid.UUID) (*entity.Schedule, error) {
+	return u.change(ctx, id, userID, func(schedule *entity.Schedule) error {
+		if !isOpenSchedule(schedule.Status) {
+			return fmt.Errorf("%w: schedule is already %s", ErrScheduleStatus, schedule.Status)
+		}
+
+		schedule.Status = entity.ScheduleStatusCancelled
+		schedule.NextRunAt = nil
+		schedule.RetryAt = nil
+		return nil
+	})
+}
+
+// change applies fn to one of the user's schedules. The row is locked, so the
+// change cannot interleave with the scheduler running an occurrence.
+func (u *scheduleUseCase) change(ctx context.Context, id, userID uuid.UUID, fn func(*entity.Schedule) error) (*entity.Schedule, error) {
+	var schedule *entity.Schedule
+	err := u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		schedule, err = u.repo.LockByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if schedule.UserID != userID {
+			return repository.ErrScheduleNotFound
+		}
+
+		if err := fn(schedule); err != nil {
+			return err
+		}
+		schedule.UpdatedAt = time.Now()
+
+		return u.repo.Update(ctx, schedule)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (u *scheduleUseCase) RunDueSchedules(ctx context.Context, limit int) (int, error) {
+	handled := 0
+	for handled < limit {
+		ran, err := u.runNextOccurrence(ctx)
+		if err != nil {
+			return handled, err
+		}
+		if !ran {
+			break
+		}
+		handled++
+	}
+
+	return handled, nil
+}
+
+// runNextOccurrence creates the transaction of one due occurrence and
+// reports whether it was handled. The transaction is created in the database
+// transaction that moves the schedule past the occurrence, so each
+// occurrence is created exactly once however many schedulers run. Its
+// idempotency key guards against the same occurrence being created twice
+// regardless.
+func (u *scheduleUseCase) runNextOccurrence(ctx context.Context) (bool, error) {
+	var due *entity.Schedule
+	err := u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		schedules, err := u.repo.LockDue(ctx, time.Now(), 1)
+		if err != nil || len(schedules) == 0 {
+			return err
+		}
+		due = schedules[0]
+
+		transaction, err := u.transactions.CreateTransaction(ctx, &CreateTransactionRequest{
+			UserID:          due.UserID,
+			Amount:          due.Amount,
+			Currency:        due.Currency,
+			TransactionType: due.TransactionType,
+			Description:     due.Description,
+			IdempotencyKey:  fmt.Sprintf("schedule:%s:%d", due.ID, due.Occurrence),
+		})
+		if err != nil {
+			return err
+		}
+
+		schedule := *due
+		schedule.LastTransactionID = &transaction.ID
+		schedule.LastError = nil
+		if err := advanceSchedule(&schedule); err != nil {
+			return err
+		}
+		schedule.UpdatedAt = time.Now()
+
+		return u.repo.Update(ctx, &schedule)
+	})
+	if err == nil || due == nil {
+		return due != nil, err
+	}
+
+	// The occurrence was rolled back. A stopped scheduler leaves it due for
+	// the next run.
+	if ctx.Err() != nil {
+		return false, err
+	}
+	log.Printf("Schedule %s occurrence %d failed: %v", due.ID, due.Occurrence, err)
+
+	// Skip an occurrence the transaction rules reject, e.g. for lack of
+	// funds, so it does not hold up the rest of the schedule. Any other
+	// failure keeps the occurrence due and retries it later under the same
+	// idempotency key.
+	rejected := isOccurrenceRejected(err)
+	return true, u.recordFailure(ctx, due.ID, due.Occurrence, err, func(schedule *entity.Schedule) {
+		if rejected {
+			advanced := *schedule
+			err := advanceSchedule(&advanced)
+			if err == nil {
+				*schedule = advanced
+				return
+			}
+
+			reason := err.Error()
+			schedule.LastError = &reason
+		}
+
+		now := time.Now()
+		retryAt := now.Add(scheduleRetryDelay(schedule, now))
+		schedule.RetryAt = &retryAt
+	})
+}
+
+// recordFailure keeps the error of a failed occurrence on its schedule and
+// applies next to move the schedule on, unless the schedule has changed since
+// the occurrence was attempted.
+func (u *scheduleUseCase) recordFailure(ctx context.Context, id uuid.UUID, occurrence int, cause error, next func(*entity.Schedule)) error {
+	return u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		schedule, err := u.repo.LockByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if schedule.Status != entity.ScheduleStatusActive || schedule.Occurrence != occurrence {
+			return nil
+		}
+
+		reason := cause.Error()
+		schedule.LastError = &reason
+		next(schedule)
+		schedule.UpdatedAt = time.Now()
+
+		return u.repo.Update(ctx, schedule)
+	})
+}
+
+// scheduleRetryDelay returns how long to wait before retrying the due
+// occurrence of a schedule: as long as it has been due, within
+// minScheduleRetryDelay and maxScheduleRetryDelay.
+func scheduleRetryDelay(schedule *entity.Schedule, now time.Time) time.Duration {
+	delay := minScheduleRetryDelay
+	if schedule.NextRunAt != nil {
+		delay = now.Sub(*schedule.NextRunAt)
+	}
+
+	if delay < minScheduleRetryDelay {
+		return minScheduleRetryDelay
+	}
+	if delay > maxScheduleRetryDelay {
+		return maxScheduleRetryDelay
+	}
+	return delay
+}
+
+// skipMissedOccurrences moves a recurring schedule past the occurrences due
+// before now.
+func skipMissedOccurrences(schedule *entity.Schedule, now time.Time) error {
+	for schedule.NextRunAt != nil && schedule.NextRunAt.Before(now) {
+		if err := advanceSchedule(schedule); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// advanceSchedule moves a schedule to its next occurrence, completing it
+// when none is left.
+func advanceSchedule(schedule *entity.Schedule) error {
+	previous := schedule.NextRunAt
+	schedule.Occurrence++
+	schedule.NextRunAt = nil
+	schedule.RetryAt = nil
+
+	if schedule.Recurrence != nil && previous != nil {
+		rule, err := recurrence.Parse(*schedule.Recurrence)
+		if err != nil {
+			return err
+		}
+
+		next, ok := rule.Next(schedule.StartAt, *previous)
+		if ok && (schedule.EndAt == nil || !next.After(*schedule.EndAt)) {
+			schedule.NextRunAt = &next
+		}
+	}
+
+	if schedule.NextRunAt == nil {
+		schedule.Status = entity.ScheduleStatusCompleted
+	}
+
+	return nil
+}
+
+// isOccurrenceRejected reports whether CreateTransaction rejected an
+// occurrence for a reason that retrying it would not change.
+func isOccurrenceRejected(err error) bool {
+	return errors.Is(err, ErrInvalidTransaction) ||
+		errors.Is(err, ErrInsufficientFunds) ||
+		errors.Is(err, ErrIdempotencyKeyReused) ||
+		errors.Is(err, money.ErrAmountOutOfRange)
+}
+
+// isOpenSchedule reports whether a schedule may still run.
+func isOpenSchedule(status string) bool {
+	return status == entity.ScheduleStatusActive || status == entity.ScheduleStatusPaused
+}
@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/domain/repository"
+	"go-api-streaming/infrastructure/stream"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StreamFilter selects the transaction events a live subscriber receives.
+// Empty fields match everything.
+type StreamFilter struct {
+	UserID           uuid.UUID
+	TransactionID    uuid.UUID
+	Statuses         []string
+	TransactionTypes []string
+	EventTypes       []string
+}
+
+// Match reports whether envelope is a transaction event matching the filter.
+func (f StreamFilter) Match(envelope *events.Envelope) bool {
+	if !contains(f.EventTypes, envelope.Type) {
+		return false
+	}
+
+	transaction, err := events.DecodeTransaction(envelope)
+	if err != nil {
+		return false
+	}
+
+	if f.UserID != uuid.Nil && transaction.UserID != f.UserID {
+		return false
+	}
+	if f.TransactionID != uuid.Nil && transaction.ID != f.TransactionID {
+		return false
+	}
+
+	return contains(f.Statuses, transaction.Status) &&
+		contains(f.TransactionTypes, transaction.TransactionType)
+}
+
+// Stream topics a WebSocket client can subscribe to.
+const (
+	TopicMyTransactions    = "my_transactions"
+	TopicTransactionPrefix = "transaction:"
+	TopicStatusPrefix      = "status:"
+)
+
+// ErrTopicForbidden is returned for topics the caller may not subscribe to.
+var ErrTopicForbidden = errors.New("topic requires admin access")
+
+// TopicFilter resolves a topic to the filter it stands for, on behalf of the
+// given caller:
+//   - "my_transactions": the caller's own transactions
+//   - "transaction:<id>": one transaction, which must be the caller's unless
+//     the caller is an admin
+//   - "status:<status>": every transaction with that status (admins only)
+func TopicFilter(topic string, userID uuid.UUID, isAdmin bool) (StreamFilter, error) {
+	switch {
+	case topic == TopicMyTransactions:
+		return StreamFilter{UserID: userID}, nil
+
+	case strings.HasPrefix(topic, TopicTransactionPrefix):
+		id, err := uuid.Parse(strings.TrimPrefix(topic, TopicTransactionPrefix))
+		if err != nil {
+			return StreamFilter{}, fmt.Errorf("invalid transaction ID in topic %q", topic)
+		}
+		filter := StreamFilter{TransactionID: id}
+		if !isAdmin {
+			filter.UserID = userID
+		}
+		return filter, nil
+
+	case strings.HasPrefix(topic, TopicStatusPrefix):
+		if !isAdmin {
+			return StreamFilter{}, ErrTopicForbidden
+		}
+		status := strings.TrimPrefix(topic, TopicStatusPrefix)
+		if status == "" {
+			return StreamFilter{}, fmt.Errorf("missing status in topic %q", topic)
+		}
+		return StreamFilter{Statuses: []string{status}}, nil
+
+	default:
+		return StreamFilter{}, fmt.Errorf("unknown topic %q", topic)
+	}
+}
+
+// contains reports whether value is in values; an empty list matches all.
+func contains(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// replayPageSize is the number of event log entries read per query during a
+// replay.
+const replayPageSize = 500
+
+// StreamUseCase hands out live subscriptions to transaction events and
+// replays missed events from the event log.
+type StreamUseCase interface {
+	Subscribe(filter StreamFilter) *stream.Subscription
+	// SubscribeFunc subscribes with an arbitrary match function, for callers
+	// whose interests change over the life of the subscription.
+	SubscribeFunc(match func(*events.Envelope) bool) *stream.Subscription
+	// Replay sends every logged event matching filter with a sequence greater
+	// than since, oldest first. It returns the last sequence it examined;
+	// live events at or below it have already been covered.
+	Replay(ctx context.Context, filter StreamFilter, since int64, send func(*events.Envelope) error) (int64, error)
+	// WaitForStatusChange blocks until the transaction's status differs from
+	// from or timeout expires, then returns the current transaction and
+	// whether its status changed. The transaction must belong to userID
+	// unless isAdmin is set.
+	WaitForStatusChange(ctx context.Context, id, userID uuid.UUID, isAdmin bool, from string, timeout time.Duration) (*entity.Transaction, bool, error)
+}
+
+type streamUseCase struct {
+	hub             *stream.Hub
+	eventLog        repository.EventLogRepository
+	transactionRepo repository.TransactionRepository
+	bufferSize      int
+}
+
+func NewStreamUseCase(
+	hub *stream.Hub,
+	eventLog repository.EventLogRepository,
+	transactionRepo repository.TransactionRepository,
+	bufferSize int,
+) StreamUseCase {
+	return &streamUseCase{
+		hub:             hub,
+		eventLog:        eventLog,
+		transactionRepo: transactionRepo,
+		bufferSize:      bufferSize,
+	}
+}
+
+func (u *streamUseCase) Subscribe(filter StreamFilter) *stream.Subscription {
+	return u.hub.Subscribe(filter.Match, u.bufferSize)
+}
+
+func (u *streamUseCase) SubscribeFunc(match func(*events.Envelope) bool) *stream.Subscription {
+	return u.hub.Subscribe(match, u.bufferSize)
+}
+
+func (u *streamUseCase) Replay(ctx context.Context, filter StreamFilter, since int64, send func(*events.Envelope) error) (int64, error) {
+	last := since
+
+	for {
+		entries, err := u.eventLog.ListSince(ctx, filter.UserID, last, replayPageSize)
+		if err != nil {
+			return last, err
+		}
+
+		for _, entry := range entries {
+			last = entry.Sequence
+
+			envelope, err := events.Decode(entry.Payload)
+			if err != nil {
+				return last, fmt.Errorf("failed to replay event %d: %w", entry.Sequence, err)
+			}
+			envelope.Sequence = entry.Sequence
+
+			if !filter.Match(envelope) {
+				continue
+			}
+			if err := send(envelope); err != nil {
+				return last, err
+			}
+		}
+
+		if len(entries) < replayPageSize {
+			return last, nil
+		}
+	}
+}
+
+// WaitForStatusChange is woken by the transaction's events on the hub rather
+// than by polling; the database is only read before waiting and once the wait
+// is over.
+func (u *streamUseCase) WaitForStatusChange(ctx context.Context, id, userID uuid.UUID, isAdmin bool, from string, timeout time.Duration) (*entity.Transaction, bool, error) {
+	// Subscribe before reading the current status so a change committed in
+	// between is not missed.
+	sub := u.Subscribe(StreamFilter{TransactionID: id})
+	defer sub.Close()
+
+	transaction, err := u.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if !isAdmin && transaction.UserID != userID {
+		return nil, false, repository.ErrTransactionNotFound
+	}
+	if transaction.Status != from {
+		return transaction, true, nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+wait:
+	for {
+		select {
+		case envelope, ok := <-sub.C:
+			if !ok {
+				// Dropped by the hub; fall back to reading the row.
+				break wait
+			}
+			changed, err := events.DecodeTransaction(envelope)
+			if err == nil && changed.Status != from {
+				break wait
+			}
+		case <-timer.C:
+			break wait
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+
+	transaction, err = u.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return transaction, transaction.Status != from, nil
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// TransactionEventPublisher records transaction events for delivery. It must
+// be called inside the database transaction that made the change, so the
+// change and its event are committed together.
+type TransactionEventPublisher interface {
+	Publish(ctx context.Context, userID uuid.UUID, event events.Event) error
+}
+
+type transactionEventPublisher struct {
+	outboxRepo repository.OutboxRepository
+	eventLog   repository.EventLogRepository
+	exchange   string
+}
+
+func NewTransactionEventPublisher(
+	outboxRepo repository.OutboxRepository,
+	eventLog repository.EventLogRepository,
+	exchange string,
+) TransactionEventPublisher {
+	return &transactionEventPublisher{
+		outboxRepo: outboxRepo,
+		eventLog:   eventLog,
+		exchange:   exchange,
+	}
+}
+
+// Publish wraps the event in a CloudEvents envelope, appends it to the event
+// log and records it in the outbox, all using the transaction carried by ctx.
+// The outbox relay delivers it to RabbitMQ.
+func (p *transactionEventPublisher) Publish(ctx context.Context, userID uuid.UUID, event events.Event) error {
+	envelope, err := events.NewEnvelope(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	eventID := uuid.MustParse(envelope.ID)
+
+	logged, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", envelope.Type, err)
+	}
+
+	entry := &entity.EventLogEntry{
+		EventID:     eventID,
+		EventType:   envelope.Type,
+		AggregateID: event.AggregateID(),
+		UserID:      userID,
+		Payload:     logged,
+		CreatedAt:   envelope.Time,
+	}
+	if err := p.eventLog.Append(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record %s event: %w", envelope.Type, err)
+	}
+
+	envelope.Sequence = entry.Sequence
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", envelope.Type, err)
+	}
+
+	outboxEvent := &entity.OutboxEvent{
+		ID:            eventID,
+		AggregateType: "transaction",
+		AggregateID:   event.AggregateID(),
+		EventType:     envelope.Type,
+		Exchange:      p.exchange,
+		RoutingKey:    event.RoutingKey(),
+		Payload:       payload,
+		CorrelationID: envelope.CorrelationID,
+		CreatedAt:     envelope.Time,
+	}
+
+	if err := p.outboxRepo.Create(ctx, outboxEvent); err != nil {
+		return fmt.Errorf("failed to record %s event: %w", envelope.Type, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"go-api-streaming/domain/entity"
+)
+
+// ErrInvalidStatusChange is wrapped by errors rejecting a status change
+// request itself: an unknown status or reason code, or a missing reason.
+var ErrInvalidStatusChange = errors.New("invalid status change")
+
+// transactionTransitions lists the statuses each status may move to. A status
+// with no entry is terminal.
+//
+//	pending ──► processing ──► success ──► reversed
+//	   │            │
+//	   ├────────────┴──► failed
+//	   ├──► cancelled
+//	   └──► expired
+//
+// pending may also go straight to success.
+var transactionTransitions = map[string][]string{
+	entity.TransactionStatusPending: {
+		entity.TransactionStatusProcessing,
+		entity.TransactionStatusSuccess,
+		entity.TransactionStatusFailed,
+		entity.TransactionStatusCancelled,
+		entity.TransactionStatusExpired,
+	},
+	entity.TransactionStatusProcessing: {
+		entity.TransactionStatusSuccess,
+		entity.TransactionStatusFailed,
+	},
+	entity.TransactionStatusSuccess: {
+		entity.TransactionStatusReversed,
+	},
+}
+
+// transactionStatuses are all known statuses.
+var transactionStatuses = []string{
+	entity.TransactionStatusPending,
+	entity.TransactionStatusProcessing,
+	entity.TransactionStatusSuccess,
+	entity.TransactionStatusFailed,
+	entity.TransactionStatusCancelled,
+	entity.TransactionStatusReversed,
+	entity.TransactionStatusExpired,
+}
+
+// statusReasons are the accepted reason codes.
+var statusReasons = []string{
+	entity.StatusReasonUserRequested,
+	entity.StatusReasonInsufficientFunds,
+	entity.StatusReasonProviderDeclined,
+	entity.StatusReasonProviderError,
+	entity.StatusReasonFraudSuspected,
+	entity.StatusReasonChargeback,
+	entity.StatusReasonDuplicate,
+	entity.StatusReasonOther,
+}
+
+// reasonRequired lists the statuses that cannot be reached without a reason.
+var reasonRequired = []string{
+	entity.TransactionStatusCancelled,
+	entity.TransactionStatusReversed,
+}
+
+// TransitionError is returned when a transaction cannot move from its current
+// status to the requested one. It maps to HTTP 409 Conflict.
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	if IsTerminalStatus(e.From) {
+		return fmt.Sprintf("transaction is %s and can no longer change status", e.From)
+	}
+	return fmt.Sprintf("transaction cannot move from %s to %s", e.From, e.To)
+}
+
+// CanTransition reports whether a transaction may move from one status to
+// another.
+func CanTransition(from, to string) bool {
+	for _, next := range transactionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminalStatus reports whether a status has no way out.
+func IsTerminalStatus(status string) bool {
+	return len(transactionTransitions[status]) == 0
+}
+
+// validateTransition checks a requested status change, including its reason
+// code, before anything is written.
+func validateTransition(from, to, reason string) error {
+	if !isKnownStatus(to) {
+		return fmt.Errorf("%w: invalid status: %s", ErrInvalidStatusChange, to)
+	}
+	if reason != "" && !containsValue(statusReasons, reason) {
+		return fmt.Errorf("%w: invalid reason: %s", ErrInvalidStatusChange, reason)
+	}
+	if reason == "" && containsValue(reasonRequired, to) {
+		return fmt.Errorf("%w: a reason is required to move a transaction to %s", ErrInvalidStatusChange, to)
+	}
+	if !CanTransition(from, to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+func isKnownStatus(status string) bool {
+	return containsValue(transactionStatuses, status)
+}
+
+// containsValue reports whether value is in values.
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
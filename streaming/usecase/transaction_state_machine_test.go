@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"errors"
+	"go-api-streaming/domain/entity"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	// Every allowed transition, written out rather than read from
+	// transactionTransitions so a change to the map shows up here.
+	allowed := map[[2]string]bool{
+		{entity.TransactionStatusPending, entity.TransactionStatusProcessing}: true,
+		{entity.TransactionStatusPending, entity.TransactionStatusSuccess}:    true,
+		{entity.TransactionStatusPending, entity.TransactionStatusFailed}:     true,
+		{entity.TransactionStatusPending, entity.TransactionStatusCancelled}:  true,
+		{entity.TransactionStatusPending, entity.TransactionStatusExpired}:    true,
+		{entity.TransactionStatusProcessing, entity.TransactionStatusSuccess}: true,
+		{entity.TransactionStatusProcessing, entity.TransactionStatusFailed}:  true,
+		{entity.TransactionStatusSuccess, entity.TransactionStatusReversed}:   true,
+	}
+
+	for _, from := range transactionStatuses {
+		for _, to := range transactionStatuses {
+			want := allowed[[2]string{from, to}]
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %t, want %t", from, to, got, want)
+			}
+		}
+	}
+
+	if CanTransition("unknown", entity.TransactionStatusSuccess) {
+		t.Errorf("CanTransition from an unknown status = true, want false")
+	}
+}
+
+func TestIsTerminalStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{status: entity.TransactionStatusPending, want: false},
+		{status: entity.TransactionStatusProcessing, want: false},
+		{status: entity.TransactionStatusSuccess, want: false},
+		{status: entity.TransactionStatusFailed, want: true},
+		{status: entity.TransactionStatusCancelled, want: true},
+		{status: entity.TransactionStatusReversed, want: true},
+		{status: entity.TransactionStatusExpired, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := IsTerminalStatus(tt.status); got != tt.want {
+			t.Errorf("IsTerminalStatus(%s) = %t, want %t", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		name           string
+		from, to       string
+		reason         string
+		wantInvalid    bool
+		wantTransition bool
+	}{
+		{name: "allowed without reason", from: entity.TransactionStatusPending, to: entity.TransactionStatusProcessing},
+		{name: "allowed with reason", from: entity.TransactionStatusProcessing, to: entity.TransactionStatusFailed, reason: entity.StatusReasonProviderDeclined},
+		{name: "cancel with reason", from: entity.TransactionStatusPending, to: entity.TransactionStatusCancelled, reason: entity.StatusReasonUserRequested},
+		{name: "reverse with reason", from: entity.TransactionStatusSuccess, to: entity.TransactionStatusReversed, reason: entity.StatusReasonChargeback},
+		{name: "cancel without reason", from: entity.TransactionStatusPending, to: entity.TransactionStatusCancelled, wantInvalid: true},
+		{name: "reverse without reason", from: entity.TransactionStatusSuccess, to: entity.TransactionStatusReversed, wantInvalid: true},
+		{name: "unknown status", from: entity.TransactionStatusPending, to: "done", wantInvalid: true},
+		{name: "unknown reason", from: entity.TransactionStatusPending, to: entity.TransactionStatusFailed, reason: "bored", wantInvalid: true},
+		{name: "forbidden", from: entity.TransactionStatusProcessing, to: entity.TransactionStatusCancelled, reason: entity.StatusReasonUserRequested, wantTransition: true},
+		{name: "from terminal", from: entity.TransactionStatusFailed, to: entity.TransactionStatusSuccess, wantTransition: true},
+		{name: "to same status", from: entity.TransactionStatusPending, to: entity.TransactionStatusPending, wantTransition: true},
+	}
+
+	for _, tt := range tests {
+		err := validateTransition(tt.from, tt.to, tt.reason)
+
+		var transitionErr *TransitionError
+		switch {
+		case tt.wantInvalid:
+			if !errors.Is(err, ErrInvalidStatusChange) {
+				t.Errorf("%s: error = %v, want ErrInvalidStatusChange", tt.name, err)
+			}
+		case tt.wantTransition:
+			if !errors.As(err, &transitionErr) || transitionErr.From != tt.from || transitionErr.To != tt.to {
+				t.Errorf("%s: error = %v, want a TransitionError from %s to %s", tt.name, err, tt.from, tt.to)
+			}
+		default:
+			if err != nil {
+				t.Errorf("%s: error = %v", tt.name, err)
+			}
+		}
+	}
+}
+
+func TestTransitionErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *TransitionError
+		want string
+	}{
+		{
+			err:  &TransitionError{From: entity.TransactionStatusProcessing, To: entity.TransactionStatusCancelled},
+			want: "transaction cannot move from processing to cancelled",
+		},
+		{
+			err:  &TransitionError{From: entity.TransactionStatusFailed, To: entity.TransactionStatusSuccess},
+			want: "transaction is failed and can no longer change status",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -1,44 +1,185 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/domain/money"
 	"go-api-streaming/domain/repository"
-	"go-api-streaming/infrastructure/messaging"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrIdempotencyKeyReused is returned when an Idempotency-Key is sent again
+// with a different request.
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+// ErrInvalidRefund is wrapped by errors rejecting a refund or reversal, or a
+// change to a transaction that would conflict with its refunds.
+var ErrInvalidRefund = errors.New("invalid refund")
+
+// ErrInvalidTransfer is wrapped by errors rejecting a transfer or a change to
+// one of its legs.
+var ErrInvalidTransfer = errors.New("invalid transfer")
+
+// ErrInvalidExchange is wrapped by errors rejecting a currency exchange.
+var ErrInvalidExchange = errors.New("invalid exchange")
+
+// ErrInvalidTransaction is wrapped by errors rejecting a transaction request
+// as invalid, such as an unsupported currency or an unknown user.
+var ErrInvalidTransaction = errors.New("invalid transaction")
+
+// ErrStatusChangeForbidden is returned when a user who is not an admin asks
+// for a status only admins may set.
+var ErrStatusChangeForbidden = errors.New("status change not allowed")
+
+// ErrInvalidFilter is wrapped by errors rejecting the filter of a listing,
+// export or summary, such as an unknown status.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// refundParentTypes maps the refund transaction types to the type of
+// transaction each may undo.
+var refundParentTypes = map[string]string{
+	entity.TransactionTypeRefund:   entity.TransactionTypePurchase,
+	entity.TransactionTypeReversal: entity.TransactionTypeDeposit,
+}
+
+// creatableTransactionTypes are the types CreateTransaction accepts. They are
+// the only ones that start out pending.
+var creatableTransactionTypes = []string{
+	entity.TransactionTypeDeposit,
+	entity.TransactionTypeWithdraw,
+	entity.TransactionTypePurchase,
+	entity.TransactionTypeRefund,
+	entity.TransactionTypeReversal,
+}
+
 type TransactionUseCase interface {
+	// CreateTransaction creates a transaction. When req carries an
+	// idempotency key already used for the same request, it returns the
+	// transaction created by that request instead of creating another.
 	CreateTransaction(ctx context.Context, req *CreateTransactionRequest) (*entity.Transaction, error)
-	GetTransaction(ctx context.Context, id uuid.UUID) (*entity.Transaction, error)
+	// CreateTransfer moves money from the sender to the recipient. Both legs
+	// are created, settled and published in one database transaction.
+	CreateTransfer(ctx context.Context, req *CreateTransferRequest) (*entity.Transfer, error)
+	// CreateExchange converts money between two of the user's wallets at the
+	// rate currently in effect, which is recorded on the transaction.
+	CreateExchange(ctx context.Context, req *CreateExchangeRequest) (*entity.Transaction, error)
+	// GetTransaction returns a transaction together with its refunds. Only
+	// the owner or an admin may read it.
+	GetTransaction(ctx context.Context, id, userID uuid.UUID, isAdmin bool) (*entity.Transaction, error)
 	GetUserTransactions(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]*entity.Transaction, error)
-	UpdateTransactionStatus(ctx context.Context, id uuid.UUID, status string) (*entity.Transaction, error)
+	// GetTransactionHistory returns the status changes of a transaction,
+	// oldest first. Only the owner or an admin may read them.
+	GetTransactionHistory(ctx context.Context, id, userID uuid.UUID, isAdmin bool) ([]*entity.TransactionStatusChange, error)
+	// UpdateTransactionStatus moves a transaction to status if the state
+	// machine allows it, returning a *TransitionError otherwise. reason is a
+	// status reason code and may be empty unless the status requires one.
+	// actorID is the user making the change and is kept in its history.
+	// Admins may set any status; other users may only cancel their own
+	// transactions.
+	UpdateTransactionStatus(ctx context.Context, id, actorID uuid.UUID, isAdmin bool, status, reason string) (*entity.Transaction, error)
 	GetAllTransactions(ctx context.Context, page, pageSize int) ([]*entity.Transaction, error)
 	GetTransactionsByStatus(ctx context.Context, status string, page, pageSize int) ([]*entity.Transaction, error)
+	// ExportTransactions calls fn for every transaction matching filter
+	// without loading the whole result into memory.
+	ExportTransactions(ctx context.Context, filter repository.TransactionFilter, fn func(*entity.Transaction) error) error
+	// SummarizeTransactions totals the transactions matching filter and
+	// converts the totals to base at the rates in effect at the given time.
+	SummarizeTransactions(ctx context.Context, filter repository.TransactionFilter, base string, at time.Time) (*entity.TransactionSummary, error)
+	// ExpirePendingTransactions moves up to limit transactions that have been
+	// pending for longer than the expiry policy allows to expired, and
+	// returns how many were moved.
+	ExpirePendingTransactions(ctx context.Context, limit int) (int, error)
+}
+
+// PendingExpiryPolicy controls how long transactions may stay pending.
+type PendingExpiryPolicy struct {
+	DefaultTTL time.Duration
+	// TTLByType overrides DefaultTTL per transaction type. A TTL of zero
+	// never expires.
+	TTLByType map[string]time.Duration
+}
+
+// TTLFor returns how long a transaction of the given type may stay pending.
+func (p PendingExpiryPolicy) TTLFor(transactionType string) time.Duration {
+	if ttl, ok := p.TTLByType[transactionType]; ok {
+		return ttl
+	}
+	return p.DefaultTTL
 }
 
 type transactionUseCase struct {
-	repo      repository.TransactionRepository
-	rabbitmq  *messaging.RabbitMQClient
-	queueName string
+	repo        repository.TransactionRepository
+	historyRepo repository.TransactionHistoryRepository
+	publisher   TransactionEventPublisher
+	wallet      WalletUseCase
+	fx          FXUseCase
+	userRepo    repository.UserProjectionRepository
+	keyRepo     repository.IdempotencyKeyRepository
+	txManager   repository.TxManager
+	keyTTL      time.Duration
+	expiry      PendingExpiryPolicy
 }
 
 type CreateTransactionRequest struct {
-	UserID          uuid.UUID `json:"user_id"`
-	Amount          float64   `json:"amount"`
-	Currency        string    `json:"currency"`
-	TransactionType string    `json:"transaction_type"`
-	Description     *string   `json:"description,omitempty"`
+	UserID          uuid.UUID    `json:"user_id"`
+	Amount          money.Amount `json:"amount"`
+	Currency        string       `json:"currency"`
+	TransactionType string       `json:"transaction_type"`
+	Description     *string      `json:"description,omitempty"`
+	// ParentTransactionID is the transaction a refund or reversal undoes.
+	ParentTransactionID *uuid.UUID `json:"parent_transaction_id,omitempty"`
+	// IdempotencyKey comes from the Idempotency-Key header.
+	IdempotencyKey string `json:"-"`
+}
+
+type CreateTransferRequest struct {
+	SenderID    uuid.UUID    `json:"-"`
+	RecipientID uuid.UUID    `json:"recipient_id"`
+	Amount      money.Amount `json:"amount"`
+	Currency    string       `json:"currency"`
+	Description *string      `json:"description,omitempty"`
+}
+
+type CreateExchangeRequest struct {
+	UserID         uuid.UUID    `json:"-"`
+	Amount         money.Amount `json:"amount"`
+	Currency       string       `json:"currency"`
+	TargetCurrency string       `json:"target_currency"`
+	Description    *string      `json:"description,omitempty"`
 }
 
-func NewTransactionUseCase(repo repository.TransactionRepository, rabbitmq *messaging.RabbitMQClient) TransactionUseCase {
+func NewTransactionUseCase(
+	repo repository.TransactionRepository,
+	historyRepo repository.TransactionHistoryRepository,
+	publisher TransactionEventPublisher,
+	wallet WalletUseCase,
+	fx FXUseCase,
+	userRepo repository.UserProjectionRepository,
+	keyRepo repository.IdempotencyKeyRepository,
+	txManager repository.TxManager,
+	keyTTL time.Duration,
+	expiry PendingExpiryPolicy,
+) TransactionUseCase {
 	return &transactionUseCase{
-		repo:      repo,
-		rabbitmq:  rabbitmq,
-		queueName: "transaction_events",
+		repo:        repo,
+		historyRepo: historyRepo,
+		publisher:   publisher,
+		wallet:      wallet,
+		fx:          fx,
+		userRepo:    userRepo,
+		keyRepo:     keyRepo,
+		txManager:   txManager,
+		keyTTL:      keyTTL,
+		expiry:      expiry,
 	}
 }
 
@@ -53,31 +194,347 @@ func (u *transactionUseCase) CreateTransaction(ctx context.Context, req *CreateT
 		return nil, err
 	}
 
+	transaction := &entity.Transaction{
+		ID:                  uuid.New(),
+		UserID:              req.UserID,
+		Amount:              req.Amount,
+		Currency:            req.Currency,
+		TransactionType:     req.TransactionType,
+		Status:              entity.TransactionStatusPending,
+		Description:         req.Description,
+		ParentTransactionID: req.ParentTransactionID,
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
+	}
+
+	// Save to database together with the outbox event
+	err := u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if req.IdempotencyKey != "" {
+			original, err := u.claimIdempotencyKey(ctx, req)
+			if err != nil {
+				return err
+			}
+			if original != nil {
+				transaction = original
+				return nil
+			}
+		}
+
+		if transaction.ParentTransactionID != nil {
+			if err := u.checkRefundable(ctx, transaction); err != nil {
+				return err
+			}
+		}
+
+		if err := u.wallet.ReserveFunds(ctx, transaction); err != nil {
+			return err
+		}
+
+		if err := u.repo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+
+		if err := u.recordStatusChange(ctx, transaction, nil, &req.UserID); err != nil {
+			return err
+		}
+
+		if req.IdempotencyKey != "" {
+			response, err := json.Marshal(transaction)
+			if err != nil {
+				return fmt.Errorf("failed to marshal idempotent response: %w", err)
+			}
+			if err := u.keyRepo.SaveResponse(ctx, req.UserID, req.IdempotencyKey, response); err != nil {
+				return err
+			}
+		}
+
+		return u.publishTransactionEvent(ctx, transaction.UserID, events.TransactionCreated{
+			Transaction: events.NewTransaction(transaction),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// claimIdempotencyKey claims req's idempotency key for this request. If the
+// key already belongs to the same request it returns the transaction that
+// request created. The key is stored in the same database transaction as the
+// new transaction, so a concurrent duplicate waits for this one to finish and
+// then gets its result, and a failed request leaves the key free.
+func (u *transactionUseCase) claimIdempotencyKey(ctx context.Context, req *CreateTransactionRequest) (*entity.Transaction, error) {
+	hash, err := requestFingerprint(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claimed, err := u.keyRepo.Claim(ctx, &entity.IdempotencyKey{
+		UserID:      req.UserID,
+		Key:         req.IdempotencyKey,
+		RequestHash: hash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(u.keyTTL),
+	})
+	if err != nil || claimed {
+		return nil, err
+	}
+
+	existing, err := u.keyRepo.Get(ctx, req.UserID, req.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing.RequestHash != hash {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	var original entity.Transaction
+	if err := json.Unmarshal(existing.Response, &original); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotent response: %w", err)
+	}
+
+	return &original, nil
+}
+
+// requestFingerprint hashes the fields of req that define the request.
+func requestFingerprint(req *CreateTransactionRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (u *transactionUseCase) CreateTransfer(ctx context.Context, req *CreateTransferRequest) (*entity.Transfer, error) {
+	if err := validateAmount(req.Amount, req.Currency); err != nil {
+		return nil, err
+	}
+	if req.RecipientID == uuid.Nil {
+		return nil, fmt.Errorf("%w: recipient_id is required", ErrInvalidTransfer)
+	}
+	if req.RecipientID == req.SenderID {
+		return nil, fmt.Errorf("%w: cannot transfer to yourself", ErrInvalidTransfer)
+	}
+
+	for _, userID := range []uuid.UUID{req.SenderID, req.RecipientID} {
+		if err := u.validateUserExists(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	transferID := uuid.New()
+	leg := func(userID uuid.UUID, transactionType string) *entity.Transaction {
+		return &entity.Transaction{
+			ID:              uuid.New(),
+			UserID:          userID,
+			Amount:          req.Amount,
+			Currency:        req.Currency,
+			TransactionType: transactionType,
+			Status:          entity.TransactionStatusSuccess,
+			TransferID:      &transferID,
+			Description:     req.Description,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+	}
+	transfer := &entity.Transfer{
+		ID:       transferID,
+		Outgoing: leg(req.SenderID, entity.TransactionTypeTransferOut),
+		Incoming: leg(req.RecipientID, entity.TransactionTypeTransferIn),
+	}
+
+	err := u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, transaction := range []*entity.Transaction{transfer.Outgoing, transfer.Incoming} {
+			if err := u.repo.Create(ctx, transaction); err != nil {
+				return fmt.Errorf("failed to create transaction: %w", err)
+			}
+			if err := u.recordStatusChange(ctx, transaction, nil, &req.SenderID); err != nil {
+				return err
+			}
+		}
+
+		if err := u.wallet.PostTransfer(ctx, transfer); err != nil {
+			return err
+		}
+
+		// Each party gets the event of its own leg. Publishing locks the
+		// party's event stream, so publish in user order, as PostTransfer
+		// locks wallets, to keep opposite transfers from deadlocking.
+		legs := []*entity.Transaction{transfer.Outgoing, transfer.Incoming}
+		if bytes.Compare(legs[1].UserID[:], legs[0].UserID[:]) < 0 {
+			legs[0], legs[1] = legs[1], legs[0]
+		}
+		for _, transaction := range legs {
+			err := u.publishTransactionEvent(ctx, transaction.UserID, events.TransactionCreated{
+				Transaction: events.NewTransaction(transaction),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (u *transactionUseCase) CreateExchange(ctx context.Context, req *CreateExchangeRequest) (*entity.Transaction, error) {
+	if err := validateAmount(req.Amount, req.Currency); err != nil {
+		return nil, err
+	}
+	if !money.IsCurrency(req.TargetCurrency) {
+		return nil, fmt.Errorf("%w: unsupported target currency: %s", ErrInvalidExchange, req.TargetCurrency)
+	}
+	if req.TargetCurrency == req.Currency {
+		return nil, fmt.Errorf("%w: target currency must differ from %s", ErrInvalidExchange, req.Currency)
+	}
+
+	if err := u.validateUserExists(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rate, err := u.fx.Rate(ctx, req.Currency, req.TargetCurrency, now)
+	if err != nil {
+		return nil, err
+	}
+
+	targetAmount, err := rate.Convert(req.Amount, money.MinorUnits(req.TargetCurrency))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExchange, err)
+	}
+	if !targetAmount.IsPositive() {
+		return nil, fmt.Errorf("%w: %s %s is too small to convert to %s", ErrInvalidExchange, req.Amount, req.Currency, req.TargetCurrency)
+	}
+	// A conversion can also land above what the amount columns hold.
+	if err := validateAmount(targetAmount, req.TargetCurrency); err != nil {
+		return nil, fmt.Errorf("%w: converted amount: %v", ErrInvalidExchange, err)
+	}
+
 	transaction := &entity.Transaction{
 		ID:              uuid.New(),
 		UserID:          req.UserID,
 		Amount:          req.Amount,
 		Currency:        req.Currency,
-		TransactionType: req.TransactionType,
-		Status:          entity.TransactionStatusPending,
+		TransactionType: entity.TransactionTypeExchange,
+		Status:          entity.TransactionStatusSuccess,
+		TargetAmount:    &targetAmount,
+		TargetCurrency:  &req.TargetCurrency,
+		ExchangeRate:    &rate,
 		Description:     req.Description,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
 	}
 
-	// Save to database
-	if err := u.repo.Create(ctx, transaction); err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
-	}
+	err = u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+
+		if err := u.recordStatusChange(ctx, transaction, nil, &req.UserID); err != nil {
+			return err
+		}
+
+		if err := u.wallet.PostExchange(ctx, transaction); err != nil {
+			return err
+		}
 
-	// Publish event to RabbitMQ
-	u.publishTransactionEvent(transaction, "transaction.created")
+		return u.publishTransactionEvent(ctx, transaction.UserID, events.TransactionCreated{
+			Transaction: events.NewTransaction(transaction),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return transaction, nil
 }
 
-func (u *transactionUseCase) GetTransaction(ctx context.Context, id uuid.UUID) (*entity.Transaction, error) {
-	return u.repo.GetByID(ctx, id)
+// checkRefundable checks a new refund or reversal against its parent. The
+// parent is locked so concurrent refunds of it are checked one at a time.
+func (u *transactionUseCase) checkRefundable(ctx context.Context, transaction *entity.Transaction) error {
+	parent, err := u.repo.LockByID(ctx, *transaction.ParentTransactionID)
+	if err != nil {
+		return err
+	}
+
+	if parent.UserID != transaction.UserID {
+		return repository.ErrTransactionNotFound
+	}
+	if parentType := refundParentTypes[transaction.TransactionType]; parent.TransactionType != parentType {
+		return fmt.Errorf("%w: a %s can only undo a %s", ErrInvalidRefund, transaction.TransactionType, parentType)
+	}
+	if parent.Status != entity.TransactionStatusSuccess {
+		return fmt.Errorf("%w: parent transaction is %s, not %s", ErrInvalidRefund, parent.Status, entity.TransactionStatusSuccess)
+	}
+	if parent.Currency != transaction.Currency {
+		return fmt.Errorf("%w: currency must match the parent transaction (%s)", ErrInvalidRefund, parent.Currency)
+	}
+
+	refunded, err := u.refundedAmount(ctx, parent.ID)
+	if err != nil {
+		return err
+	}
+	remaining, err := parent.Amount.Sub(refunded)
+	if err != nil {
+		return err
+	}
+	if transaction.Amount.Cmp(remaining) > 0 {
+		return fmt.Errorf("%w: only %s %s of the parent transaction is left to refund", ErrInvalidRefund, remaining, parent.Currency)
+	}
+
+	return nil
+}
+
+// refundedAmount sums the refunds of a transaction that have not failed,
+// been cancelled, been reversed or expired.
+func (u *transactionUseCase) refundedAmount(ctx context.Context, parentID uuid.UUID) (money.Amount, error) {
+	refunds, err := u.repo.GetByParentID(ctx, parentID)
+	if err != nil {
+		return money.Zero, err
+	}
+
+	total := money.Zero
+	for _, refund := range refunds {
+		switch refund.Status {
+		case entity.TransactionStatusFailed, entity.TransactionStatusCancelled, entity.TransactionStatusReversed, entity.TransactionStatusExpired:
+			continue
+		}
+		total, err = total.Add(refund.Amount)
+		if err != nil {
+			return money.Zero, err
+		}
+	}
+
+	return total, nil
+}
+
+func (u *transactionUseCase) GetTransaction(ctx context.Context, id, userID uuid.UUID, isAdmin bool) (*entity.Transaction, error) {
+	transaction, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hide other users' transactions the same way missing ones are reported.
+	if !isAdmin && transaction.UserID != userID {
+		return nil, repository.ErrTransactionNotFound
+	}
+
+	transaction.Refunds, err = u.repo.GetByParentID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
 }
 
 func (u *transactionUseCase) GetUserTransactions(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]*entity.Transaction, error) {
@@ -92,28 +549,111 @@ func (u *transactionUseCase) GetUserTransactions(ctx context.Context, userID uui
 	return u.repo.GetByUserID(ctx, userID, pageSize, offset)
 }
 
-func (u *transactionUseCase) UpdateTransactionStatus(ctx context.Context, id uuid.UUID, status string) (*entity.Transaction, error) {
-	// Validate status
-	if !u.isValidStatus(status) {
-		return nil, fmt.Errorf("invalid status: %s", status)
+func (u *transactionUseCase) GetTransactionHistory(ctx context.Context, id, userID uuid.UUID, isAdmin bool) ([]*entity.TransactionStatusChange, error) {
+	transaction, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get existing transaction
-	transaction, err := u.repo.GetByID(ctx, id)
+	// Hide other users' transactions the same way missing ones are reported.
+	if !isAdmin && transaction.UserID != userID {
+		return nil, repository.ErrTransactionNotFound
+	}
+
+	history, err := u.historyRepo.ListByTransactionID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if history == nil {
+		history = []*entity.TransactionStatusChange{}
+	}
 
-	// Update status
-	transaction.Status = status
-	transaction.UpdatedAt = time.Now()
+	return history, nil
+}
 
-	if err := u.repo.Update(ctx, transaction); err != nil {
-		return nil, fmt.Errorf("failed to update transaction: %w", err)
+func (u *transactionUseCase) UpdateTransactionStatus(ctx context.Context, id, actorID uuid.UUID, isAdmin bool, status, reason string) (*entity.Transaction, error) {
+	// Validate status
+	if !u.isValidStatus(status) {
+		return nil, fmt.Errorf("%w: invalid status: %s", ErrInvalidStatusChange, status)
 	}
 
-	// Publish event to RabbitMQ
-	u.publishTransactionEvent(transaction, "transaction.updated")
+	var transaction *entity.Transaction
+	err := u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Lock the row so the transition is checked against the status it
+		// actually replaces.
+		var err error
+		transaction, err = u.repo.LockByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// Settling a transaction posts it to the ledger, so only admins may
+		// do it. Owners may cancel, and other users' transactions are
+		// reported as missing.
+		if !isAdmin {
+			if transaction.UserID != actorID {
+				return repository.ErrTransactionNotFound
+			}
+			if status != entity.TransactionStatusCancelled {
+				return fmt.Errorf("%w: only an admin may move a transaction to %s", ErrStatusChangeForbidden, status)
+			}
+		}
+
+		// The legs of a transfer only exist together.
+		if transaction.TransferID != nil {
+			return fmt.Errorf("%w: the status of a transfer leg cannot be changed", ErrInvalidTransfer)
+		}
+
+		previousStatus := transaction.Status
+		if err := validateTransition(previousStatus, status, reason); err != nil {
+			return err
+		}
+
+		// Reversing a transaction as a whole would undo its refunded part a
+		// second time.
+		if status == entity.TransactionStatusReversed {
+			refunded, err := u.refundedAmount(ctx, transaction.ID)
+			if err != nil {
+				return err
+			}
+			if !refunded.IsZero() {
+				return fmt.Errorf("%w: transaction has refunds and cannot be reversed", ErrInvalidRefund)
+			}
+		}
+
+		transaction.Status = status
+		transaction.StatusReason = nil
+		if reason != "" {
+			transaction.StatusReason = &reason
+		}
+		transaction.UpdatedAt = time.Now()
+
+		if err := u.repo.Update(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+
+		if err := u.recordStatusChange(ctx, transaction, &previousStatus, &actorID); err != nil {
+			return err
+		}
+
+		switch status {
+		case entity.TransactionStatusSuccess:
+			err = u.wallet.PostTransaction(ctx, transaction)
+		case entity.TransactionStatusReversed:
+			err = u.wallet.ReverseTransaction(ctx, transaction)
+		}
+		if err != nil {
+			return err
+		}
+
+		return u.publishTransactionEvent(ctx, transaction.UserID, events.TransactionUpdated{
+			Transaction:    events.NewTransaction(transaction),
+			PreviousStatus: previousStatus,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return transaction, nil
 }
@@ -132,7 +672,7 @@ func (u *transactionUseCase) GetAllTransactions(ctx context.Context, page, pageS
 
 func (u *transactionUseCase) GetTransactionsByStatus(ctx context.Context, status string, page, pageSize int) ([]*entity.Transaction, error) {
 	if !u.isValidStatus(status) {
-		return nil, fmt.Errorf("invalid status: %s", status)
+		return nil, fmt.Errorf("%w: invalid status: %s", ErrInvalidFilter, status)
 	}
 
 	if page < 1 {
@@ -146,56 +686,229 @@ func (u *transactionUseCase) GetTransactionsByStatus(ctx context.Context, status
 	return u.repo.GetByStatus(ctx, status, pageSize, offset)
 }
 
-func (u *transactionUseCase) validateCreateRequest(req *CreateTransactionRequest) error {
-	if req.Amount <= 0 {
-		return fmt.Errorf("amount must be greater than 0")
+func (u *transactionUseCase) ExportTransactions(ctx context.Context, filter repository.TransactionFilter, fn func(*entity.Transaction) error) error {
+	if filter.Status != "" && !u.isValidStatus(filter.Status) {
+		return fmt.Errorf("%w: invalid status: %s", ErrInvalidFilter, filter.Status)
 	}
 
-	if req.Currency == "" {
-		return fmt.Errorf("currency is required")
+	return u.repo.Stream(ctx, filter, fn)
+}
+
+func (u *transactionUseCase) SummarizeTransactions(ctx context.Context, filter repository.TransactionFilter, base string, at time.Time) (*entity.TransactionSummary, error) {
+	if filter.Status != "" && !u.isValidStatus(filter.Status) {
+		return nil, fmt.Errorf("%w: invalid status: %s", ErrInvalidFilter, filter.Status)
+	}
+	if !money.IsCurrency(base) {
+		return nil, fmt.Errorf("%w: unsupported base currency: %s", ErrInvalidFilter, base)
+	}
+
+	totals, err := u.repo.Summarize(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &entity.TransactionSummary{
+		BaseCurrency:    base,
+		RatesAt:         at,
+		Totals:          totals,
+		ConvertedByType: make(map[string]money.Amount),
+	}
+	if summary.Totals == nil {
+		summary.Totals = []*entity.TransactionTotal{}
+	}
+
+	places := money.MinorUnits(base)
+	for _, total := range totals {
+		rate, err := u.fx.Rate(ctx, total.Currency, base, at)
+		if err != nil {
+			return nil, err
+		}
+
+		total.ConvertedAmount, err = rate.Convert(total.Amount, places)
+		if err != nil {
+			return nil, err
+		}
+		summary.ConvertedByType[total.TransactionType], err = summary.ConvertedByType[total.TransactionType].Add(total.ConvertedAmount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return summary, nil
+}
+
+func (u *transactionUseCase) ExpirePendingTransactions(ctx context.Context, limit int) (int, error) {
+	expired := 0
+	now := time.Now()
+	for _, transactionType := range creatableTransactionTypes {
+		ttl := u.expiry.TTLFor(transactionType)
+		if ttl <= 0 {
+			continue
+		}
+
+		for expired < limit {
+			ok, err := u.expireNext(ctx, transactionType, now.Add(-ttl), now)
+			if err != nil {
+				return expired, err
+			}
+			if !ok {
+				break
+			}
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// expireNext expires the oldest transaction of the type pending since before
+// cutoff and reports whether there was one. Each transaction is expired in
+// its own database transaction: expiring appends to the owner's event log,
+// and appending for several users in one database transaction could deadlock
+// with one appending for the same users in another order.
+func (u *transactionUseCase) expireNext(ctx context.Context, transactionType string, cutoff, now time.Time) (bool, error) {
+	found := false
+	err := u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		transactions, err := u.repo.LockExpiredPending(ctx, transactionType, cutoff, 1)
+		if err != nil || len(transactions) == 0 {
+			return err
+		}
+		found = true
+
+		return u.expireTransaction(ctx, transactions[0], now)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// expireTransaction moves a locked pending transaction to expired. Its
+// reservation is released with it, since only pending and processing
+// transactions reserve funds.
+func (u *transactionUseCase) expireTransaction(ctx context.Context, transaction *entity.Transaction, now time.Time) error {
+	previousStatus := transaction.Status
+	transaction.Status = entity.TransactionStatusExpired
+	transaction.StatusReason = nil
+	transaction.UpdatedAt = now
+
+	if err := u.repo.Update(ctx, transaction); err != nil {
+		return fmt.Errorf("failed to update transaction: %w", err)
+	}
+
+	// Nobody acted on the transaction, so the change has no actor.
+	if err := u.recordStatusChange(ctx, transaction, &previousStatus, nil); err != nil {
+		return err
+	}
+
+	return u.publishTransactionEvent(ctx, transaction.UserID, events.TransactionUpdated{
+		Transaction:    events.NewTransaction(transaction),
+		PreviousStatus: previousStatus,
+	})
+}
+
+func (u *transactionUseCase) validateCreateRequest(req *CreateTransactionRequest) error {
+	if err := validateAmount(req.Amount, req.Currency); err != nil {
+		return err
 	}
 
 	if !u.isValidTransactionType(req.TransactionType) {
-		return fmt.Errorf("invalid transaction type: %s", req.TransactionType)
+		return fmt.Errorf("%w type: %s", ErrInvalidTransaction, req.TransactionType)
+	}
+
+	_, isRefund := refundParentTypes[req.TransactionType]
+	if isRefund && req.ParentTransactionID == nil {
+		return fmt.Errorf("%w: parent_transaction_id is required for a %s", ErrInvalidRefund, req.TransactionType)
+	}
+	if !isRefund && req.ParentTransactionID != nil {
+		return fmt.Errorf("%w: parent_transaction_id is only allowed for refunds and reversals", ErrInvalidRefund)
 	}
 
 	return nil
 }
 
+func validateAmount(amount money.Amount, currency string) error {
+	if !amount.IsPositive() {
+		return fmt.Errorf("%w: amount must be greater than 0", ErrInvalidTransaction)
+	}
+
+	if amount.Cmp(money.MaxAmount) > 0 {
+		return fmt.Errorf("%w: amount must be at most %s", money.ErrAmountOutOfRange, money.MaxAmount)
+	}
+
+	if currency == "" {
+		return fmt.Errorf("%w: currency is required", ErrInvalidTransaction)
+	}
+
+	if !money.IsCurrency(currency) {
+		return fmt.Errorf("%w: unsupported currency: %s (expected an ISO 4217 code such as USD)", ErrInvalidTransaction, currency)
+	}
+
+	if places := money.MinorUnits(currency); amount.Decimals() > places {
+		return fmt.Errorf("%w: amount %s has more decimal places than %s allows (%d)", ErrInvalidTransaction, amount, currency, places)
+	}
+
+	return nil
+}
+
+// validateUserExists checks the user against the projection built from the
+// authentication service's user events. Users created before the projection
+// existed are not in it yet, so a miss falls back to the users table.
 func (u *transactionUseCase) validateUserExists(ctx context.Context, userID uuid.UUID) error {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to validate user: %w", err)
+	}
+
+	if user != nil {
+		if user.Deleted {
+			return fmt.Errorf("%w: user with ID %s has been deleted", ErrInvalidTransaction, userID)
+		}
+		return nil
+	}
+
 	exists, err := u.repo.UserExists(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to validate user: %w", err)
 	}
-	
+
 	if !exists {
-		return fmt.Errorf("user with ID %s does not exist, please ensure the user is created before creating a transaction", userID)
+		return fmt.Errorf("%w: user with ID %s does not exist, please ensure the user is created before creating a transaction", ErrInvalidTransaction, userID)
 	}
-	
+
 	return nil
 }
 
 func (u *transactionUseCase) isValidTransactionType(transactionType string) bool {
-	return transactionType == entity.TransactionTypeDeposit ||
-		transactionType == entity.TransactionTypeWithdraw ||
-		transactionType == entity.TransactionTypePurchase
+	return containsValue(creatableTransactionTypes, transactionType)
 }
 
 func (u *transactionUseCase) isValidStatus(status string) bool {
-	return status == entity.TransactionStatusPending ||
-		status == entity.TransactionStatusSuccess ||
-		status == entity.TransactionStatusFailed
+	return isKnownStatus(status)
 }
 
-func (u *transactionUseCase) publishTransactionEvent(transaction *entity.Transaction, eventType string) {
-	event := map[string]interface{}{
-		"event_type":  eventType,
-		"transaction": transaction,
-		"timestamp":   time.Now(),
+// recordStatusChange appends the status transaction has just taken to its
+// history. It must run in the database transaction that made the change, so
+// the history cannot miss a change or record one that was rolled back.
+func (u *transactionUseCase) recordStatusChange(ctx context.Context, transaction *entity.Transaction, previousStatus *string, actorID *uuid.UUID) error {
+	change := &entity.TransactionStatusChange{
+		TransactionID:  transaction.ID,
+		PreviousStatus: previousStatus,
+		Status:         transaction.Status,
+		ActorID:        actorID,
+		Reason:         transaction.StatusReason,
+		CreatedAt:      transaction.UpdatedAt,
 	}
-
-	if err := u.rabbitmq.PublishMessage(u.queueName, event); err != nil {
-		// Log error but don't fail the operation
-		fmt.Printf("Warning: failed to publish event: %v\n", err)
+	if requestID := events.CorrelationID(ctx); requestID != "" {
+		change.RequestID = &requestID
 	}
+
+	return u.historyRepo.Append(ctx, change)
+}
+
+// publishTransactionEvent records the event through the event publisher
+// using the transaction carried by ctx.
+func (u *transactionUseCase) publishTransactionEvent(ctx context.Context, userID uuid.UUID, event events.Event) error {
+	return u.publisher.Publish(ctx, userID, event)
 }
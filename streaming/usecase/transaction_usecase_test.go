@@ -0,0 +1,662 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/domain/money"
+	"go-api-streaming/domain/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestExpirePendingTransactions(t *testing.T) {
+	db := &memDB{}
+	u := newTestTransactionUseCase(db)
+	u.expiry = PendingExpiryPolicy{
+		DefaultTTL: time.Hour,
+		TTLByType: map[string]time.Duration{
+			entity.TransactionTypeWithdraw: 10 * time.Minute,
+			entity.TransactionTypePurchase: 0,
+		},
+	}
+
+	userID := db.addUser()
+	now := time.Now()
+	pending := func(transactionType, status string, age time.Duration) uuid.UUID {
+		transaction := &entity.Transaction{
+			ID:              uuid.New(),
+			UserID:          userID,
+			Amount:          money.MustParse("10"),
+			Currency:        "USD",
+			TransactionType: transactionType,
+			Status:          status,
+			CreatedAt:       now.Add(-age),
+			UpdatedAt:       now.Add(-age),
+		}
+		db.addTransaction(transaction)
+		return transaction.ID
+	}
+
+	tests := []struct {
+		name        string
+		id          uuid.UUID
+		wantExpired bool
+	}{
+		{name: "deposit past the default TTL", id: pending(entity.TransactionTypeDeposit, entity.TransactionStatusPending, 2*time.Hour), wantExpired: true},
+		{name: "deposit within the default TTL", id: pending(entity.TransactionTypeDeposit, entity.TransactionStatusPending, 30*time.Minute)},
+		{name: "withdrawal past its own TTL", id: pending(entity.TransactionTypeWithdraw, entity.TransactionStatusPending, 20*time.Minute), wantExpired: true},
+		{name: "withdrawal within its own TTL", id: pending(entity.TransactionTypeWithdraw, entity.TransactionStatusPending, 5*time.Minute)},
+		{name: "purchase that never expires", id: pending(entity.TransactionTypePurchase, entity.TransactionStatusPending, 30*24*time.Hour)},
+		{name: "old processing deposit", id: pending(entity.TransactionTypeDeposit, entity.TransactionStatusProcessing, 2*time.Hour)},
+		{name: "old successful deposit", id: pending(entity.TransactionTypeDeposit, entity.TransactionStatusSuccess, 2*time.Hour)},
+	}
+
+	expired, err := u.ExpirePendingTransactions(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("ExpirePendingTransactions error = %v", err)
+	}
+	if expired != 2 {
+		t.Errorf("ExpirePendingTransactions = %d, want 2", expired)
+	}
+
+	for _, tt := range tests {
+		transaction := db.transaction(tt.id)
+		history := db.historyOf(tt.id)
+		if !tt.wantExpired {
+			if transaction.Status == entity.TransactionStatusExpired || len(history) != 0 {
+				t.Errorf("%s: status %s with %d history rows, want it left alone", tt.name, transaction.Status, len(history))
+			}
+			continue
+		}
+
+		if transaction.Status != entity.TransactionStatusExpired {
+			t.Errorf("%s: status = %s, want %s", tt.name, transaction.Status, entity.TransactionStatusExpired)
+		}
+		if len(history) != 1 || history[0].PreviousStatus == nil || *history[0].PreviousStatus != entity.TransactionStatusPending ||
+			history[0].Status != entity.TransactionStatusExpired || history[0].ActorID != nil {
+			t.Errorf("%s: history = %+v, want one change from pending to expired without an actor", tt.name, history)
+		}
+	}
+
+	if len(db.published) != 2 {
+		t.Fatalf("published %d events, want 2", len(db.published))
+	}
+	for _, published := range db.published {
+		updated, ok := published.Event.(events.TransactionUpdated)
+		if !ok || updated.PreviousStatus != entity.TransactionStatusPending || updated.Transaction.Status != entity.TransactionStatusExpired {
+			t.Errorf("published %+v, want a transaction.updated event from pending to expired", published.Event)
+		}
+	}
+
+	// Every expiry commits on its own, so no database transaction appends
+	// events for more than one user.
+	for i, published := range db.commits {
+		if published > 1 {
+			t.Errorf("database transaction %d published %d events, want at most 1", i, published)
+		}
+	}
+}
+
+func TestExpirePendingTransactionsLimit(t *testing.T) {
+	db := &memDB{}
+	u := newTestTransactionUseCase(db)
+	u.expiry = PendingExpiryPolicy{DefaultTTL: time.Minute}
+
+	now := time.Now()
+	var ids []uuid.UUID
+	for _, age := range []time.Duration{3 * time.Hour, time.Hour, 2 * time.Hour} {
+		transaction := &entity.Transaction{
+			ID:              uuid.New(),
+			UserID:          db.addUser(),
+			Amount:          money.MustParse("1"),
+			Currency:        "EUR",
+			TransactionType: entity.TransactionTypeDeposit,
+			Status:          entity.TransactionStatusPending,
+			CreatedAt:       now.Add(-age),
+		}
+		db.addTransaction(transaction)
+		ids = append(ids, transaction.ID)
+	}
+
+	expired, err := u.ExpirePendingTransactions(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("ExpirePendingTransactions error = %v", err)
+	}
+	if expired != 2 {
+		t.Errorf("ExpirePendingTransactions = %d, want 2", expired)
+	}
+
+	// The oldest go first.
+	want := []string{entity.TransactionStatusExpired, entity.TransactionStatusPending, entity.TransactionStatusExpired}
+	for i, id := range ids {
+		if got := db.transaction(id).Status; got != want[i] {
+			t.Errorf("transaction %d: status = %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestCreateTransactionIdempotency(t *testing.T) {
+	db := &memDB{}
+	u := newTestTransactionUseCase(db)
+	ctx := context.Background()
+	userID := db.addUser()
+
+	request := func(amount string) *CreateTransactionRequest {
+		return &CreateTransactionRequest{
+			UserID:          userID,
+			Amount:          money.MustParse(amount),
+			Currency:        "USD",
+			TransactionType: entity.TransactionTypeDeposit,
+			IdempotencyKey:  "key-1",
+		}
+	}
+
+	first, err := u.CreateTransaction(ctx, request("25"))
+	if err != nil {
+		t.Fatalf("CreateTransaction error = %v", err)
+	}
+	replayed, err := u.CreateTransaction(ctx, request("25"))
+	if err != nil {
+		t.Fatalf("replayed CreateTransaction error = %v", err)
+	}
+	if replayed.ID != first.ID || replayed.Amount.Cmp(first.Amount) != 0 || replayed.Status != first.Status ||
+		!replayed.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("replay returned %+v, want the original %+v", replayed, first)
+	}
+	if len(db.transactions) != 1 || len(db.published) != 1 || len(db.history) != 1 {
+		t.Errorf("stored %d transactions, %d events and %d history rows, want 1 of each",
+			len(db.transactions), len(db.published), len(db.history))
+	}
+
+	// The same key with a different body is refused and changes nothing.
+	if _, err := u.CreateTransaction(ctx, request("26")); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("CreateTransaction with a reused key error = %v, want ErrIdempotencyKeyReused", err)
+	}
+	if len(db.transactions) != 1 {
+		t.Errorf("stored %d transactions, want 1", len(db.transactions))
+	}
+
+	// Keys belong to a user, so another user may use the same one.
+	other := request("25")
+	other.UserID = db.addUser()
+	created, err := u.CreateTransaction(ctx, other)
+	if err != nil {
+		t.Fatalf("CreateTransaction for another user error = %v", err)
+	}
+	if created.ID == first.ID {
+		t.Errorf("another user's request replayed the first user's transaction")
+	}
+}
+
+func TestCreateTransactionIdempotencyAfterFailure(t *testing.T) {
+	db := &memDB{}
+	u := newTestTransactionUseCase(db)
+	ctx := context.Background()
+	userID := db.addUser()
+
+	req := &CreateTransactionRequest{
+		UserID:          userID,
+		Amount:          money.MustParse("40"),
+		Currency:        "USD",
+		TransactionType: entity.TransactionTypeWithdraw,
+		IdempotencyKey:  "withdraw-1",
+	}
+	if _, err := u.CreateTransaction(ctx, req); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("CreateTransaction error = %v, want ErrInsufficientFunds", err)
+	}
+
+	// The failed request released its key, so a retry once funds arrive
+	// creates the transaction.
+	db.deposit(userID, money.MustParse("40"), "USD")
+	transaction, err := u.CreateTransaction(ctx, req)
+	if err != nil {
+		t.Fatalf("retried CreateTransaction error = %v", err)
+	}
+	if transaction.Status != entity.TransactionStatusPending || len(db.transactions) != 1 {
+		t.Errorf("retry created %+v with %d stored transactions, want one pending transaction", transaction, len(db.transactions))
+	}
+}
+
+func TestCreateRefund(t *testing.T) {
+	db := &memDB{}
+	u := newTestTransactionUseCase(db)
+	ctx := context.Background()
+	owner, stranger := db.addUser(), db.addUser()
+
+	purchase := newTestTransaction(owner, entity.TransactionTypePurchase, entity.TransactionStatusSuccess, "100", "USD")
+	db.addTransaction(purchase)
+	deposit := newTestTransaction(owner, entity.TransactionTypeDeposit, entity.TransactionStatusSuccess, "100", "USD")
+	db.addTransaction(deposit)
+	pendingPurchase := newTestTransaction(owner, entity.TransactionTypePurchase, entity.TransactionStatusPending, "100", "USD")
+	db.addTransaction(pendingPurchase)
+
+	refund := func(userID uuid.UUID, parent *entity.Transaction, amount, currency string) (*entity.Transaction, error) {
+		return u.CreateTransaction(ctx, &CreateTransactionRequest{
+			UserID:              userID,
+			Amount:              money.MustParse(amount),
+			Currency:            currency,
+			TransactionType:     entity.TransactionTypeRefund,
+			ParentTransactionID: &parent.ID,
+		})
+	}
+
+	first, err := refund(owner, purchase, "60", "USD")
+	if err != nil {
+		t.Fatalf("refund of 60 error = %v", err)
+	}
+	if _, err := refund(owner, purchase, "40.01", "USD"); !errors.Is(err, ErrInvalidRefund) {
+		t.Errorf("refund beyond the remaining 40 error = %v, want ErrInvalidRefund", err)
+	}
+	if _, err := refund(owner, purchase, "40", "USD"); err != nil {
+		t.Fatalf("refund of the remaining 40 error = %v", err)
+	}
+	if _, err := refund(owner, purchase, "0.01", "USD"); !errors.Is(err, ErrInvalidRefund) {
+		t.Errorf("refund of a fully refunded purchase error = %v, want ErrInvalidRefund", err)
+	}
+
+	// A cancelled refund no longer counts against the purchase.
+	if _, err := u.UpdateTransactionStatus(ctx, first.ID, owner, false, entity.TransactionStatusCancelled, entity.StatusReasonUserRequested); err != nil {
+		t.Fatalf("cancelling the first refund error = %v", err)
+	}
+	if _, err := refund(owner, purchase, "60", "USD"); err != nil {
+		t.Errorf("refund after cancelling one error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		parent   *entity.Transaction
+		currency string
+		want     error
+	}{
+		{name: "another user's purchase", userID: stranger, parent: purchase, currency: "USD", want: repository.ErrTransactionNotFound},
+		{name: "a deposit", userID: owner, parent: deposit, currency: "USD", want: ErrInvalidRefund},
+		{name: "a pending purchase", userID: owner, parent: pendingPurchase, currency: "USD", want: ErrInvalidRefund},
+		{name: "another currency", userID: owner, parent: purchase, currency: "EUR", want: ErrInvalidRefund},
+	}
+	for _, tt := range tests {
+		count := len(db.transactions)
+		if _, err := refund(tt.userID, tt.parent, "1", tt.currency); !errors.Is(err, tt.want) {
+			t.Errorf("refund of %s error = %v, want %v", tt.name, err, tt.want)
+		}
+		if len(db.transactions) != count {
+			t.Errorf("refund of %s stored a transaction", tt.name)
+		}
+	}
+}
+
+func TestGetTransaction(t *testing.T) {
+	db := &memDB{}
+	u := newTestTransactionUseCase(db)
+	ctx := context.Background()
+	owner, stranger := db.addUser(), db.addUser()
+
+	purchase := newTestTransaction(owner, entity.TransactionTypePurchase, entity.TransactionStatusSuccess, "100", "USD")
+	db.addTransaction(purchase)
+	refund := newTestTransaction(owner, entity.TransactionTypeRefund, entity.TransactionStatusSuccess, "30", "USD")
+	refund.ParentTransactionID = &purchase.ID
+	db.addTransaction(refund)
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		isAdmin bool
+		wantErr error
+	}{
+		{name: "owner", userID: owner},
+		{name: "admin", userID: stranger, isAdmin: true},
+		{name: "another user", userID: stranger, wantErr: repository.ErrTransactionNotFound},
+	}
+	for _, tt := range tests {
+		transaction, err := u.GetTransaction(ctx, purchase.ID, tt.userID, tt.isAdmin)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) || transaction != nil {
+				t.Errorf("%s: GetTransaction = %v, %v, want %v", tt.name, transaction, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: GetTransaction error = %v", tt.name, err)
+		}
+		if len(transaction.Refunds) != 1 || transaction.Refunds[0].ID != refund.ID {
+			t.Errorf("%s: refunds = %v, want the one refund", tt.name, transaction.Refunds)
+		}
+	}
+}
+
+func TestCreateTransfer(t *testing.T) {
+	db := &memDB{}
+	u := newTestTransactionUseCase(db)
+	ctx := context.Background()
+	sender, recipient := db.addUser(), db.addUser()
+	db.deposit(sender, money.MustParse("50"), "USD")
+	entries := len(db.entries)
+
+	transfer := func(amount string) (*entity.Transfer, error) {
+		return u.CreateTransfer(ctx, &CreateTransferRequest{
+			SenderID:    sender,
+			RecipientID: recipient,
+			Amount:      money.MustParse(amount),
+			Currency:    "USD",
+		})
+	}
+
+	// Nothing of a transfer the sender cannot cover is kept: no legs, no
+	// history, no postings and no events.
+	if _, err := transfer("50.01"); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("CreateTransfer above the balance error = %v, want ErrInsufficientFunds", err)
+	}
+	if len(db.transactions) != 0 || len(db.history) != 0 || len(db.entries) != entries || len(db.published) != 0 {
+		t.Errorf("failed transfer left %d transactions, %d history rows, %d new entries and %d events, want none",
+			len(db.transactions), len(db.history), len(db.entries)-entries, len(db.published))
+	}
+	if got := db.balance(sender, "USD"); got.Cmp(money.MustParse("50")) != 0 {
+		t.Errorf("sender balance after failed transfer = %s, want 50", got)
+	}
+
+	created, err := transfer("30")
+	if err != nil {
+		t.Fatalf("CreateTransfer error = %v", err)
+	}
+	for _, leg := range []*entity.Transaction{created.Outgoing, created.Incoming} {
+		stored := db.transaction(leg.ID)
+		if stored == nil || stored.Status != entity.TransactionStatusSuccess || stored.TransferID == nil || *stored.TransferID != created.ID {
+			t.Errorf("leg %s stored as %+v, want a successful leg of transfer %s", leg.TransactionType, stored, created.ID)
+		}
+	}
+	if got := db.balance(sender, "USD"); got.Cmp(money.MustParse("20")) != 0 {
+		t.Errorf("sender balance = %s, want 20", got)
+	}
+	if got := db.balance(recipient, "USD"); got.Cmp(money.MustParse("30")) != 0 {
+		t.Errorf("recipient balance = %s, want 30", got)
+	}
+
+	// Each party gets the event of its own leg.
+	legOwners := map[uuid.UUID]uuid.UUID{created.Outgoing.ID: sender, created.Incoming.ID: recipient}
+	if len(db.published) != 2 {
+		t.Fatalf("published %d events, want 2", len(db.published))
+	}
+	for _, published := range db.published {
+		event, ok := published.Event.(events.TransactionCreated)
+		if !ok || legOwners[event.Transaction.ID] != published.UserID {
+			t.Errorf("published %+v for %s, want each leg's event for its owner", published.Event, published.UserID)
+		}
+	}
+}
+
+func TestCreateTransferValidation(t *testing.T) {
+	db := &memDB{}
+	u := newTestTransactionUseCase(db)
+	sender := db.addUser()
+	db.deposit(sender, money.MustParse("50"), "USD")
+
+	tests := []struct {
+		name        string
+		recipientID uuid.UUID
+		want        error
+	}{
+		{name: "no recipient", recipientID: uuid.Nil, want: ErrInvalidTransfer},
+		{name: "to the sender", recipientID: sender, want: ErrInvalidTransfer},
+		{name: "unknown recipient", recipientID: uuid.New(), want: ErrInvalidTransaction},
+	}
+	for _, tt := range tests {
+		_, err := u.CreateTransfer(context.Background(), &CreateTransferRequest{
+			SenderID:    sender,
+			RecipientID: tt.recipientID,
+			Amount:      money.MustParse("10"),
+			Currency:    "USD",
+		})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: CreateTransfer error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if len(db.transactions) != 0 {
+		t.Errorf("stored %d transactions, want none", len(db.transactions))
+	}
+}
+
+func TestCreateExchange(t *testing.T) {
+	now := time.Now()
+	rates := []entity.FXRate{
+		{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: mustRate(t, "0.5"), EffectiveAt: now.Add(-2 * time.Hour)},
+		{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: mustRate(t, "0.9215"), EffectiveAt: now.Add(-time.Hour)},
+		{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: mustRate(t, "2"), EffectiveAt: now.Add(time.Hour)},
+		{BaseCurrency: "USD", QuoteCurrency: "JPY", Rate: mustRate(t, "150.555"), EffectiveAt: now.Add(-time.Hour)},
+		{BaseCurrency: "GBP", QuoteCurrency: "USD", Rate: mustRate(t, "1.25"), EffectiveAt: now.Add(-time.Hour)},
+		{BaseCurrency: "VND", QuoteCurrency: "USD", Rate: mustRate(t, "0.00004"), EffectiveAt: now.Add(-time.Hour)},
+	}
+
+	tests := []struct {
+		name       string
+		amount     string
+		currency   string
+		target     string
+		wantRate   string
+		wantTarget string
+		wantErr    error
+	}{
+		{name: "latest rate in effect", amount: "10.01", currency: "USD", target: "EUR", wantRate: "0.9215", wantTarget: "9.22"},
+		{name: "rounded up to cents", amount: "10.05", currency: "USD", target: "EUR", wantRate: "0.9215", wantTarget: "9.26"},
+		{name: "half rounded away from zero", amount: "1", currency: "USD", target: "JPY", wantRate: "150.555", wantTarget: "151"},
+		{name: "inverse of the opposite pair", amount: "10", currency: "USD", target: "GBP", wantRate: "0.8", wantTarget: "8"},
+		{name: "rounds to nothing", amount: "100", currency: "VND", target: "USD", wantErr: ErrInvalidExchange},
+		{name: "no rate", amount: "10", currency: "USD", target: "CHF", wantErr: ErrNoExchangeRate},
+		{name: "same currency", amount: "10", currency: "USD", target: "USD", wantErr: ErrInvalidExchange},
+		{name: "unknown currency", amount: "10", currency: "USD", target: "XYZ", wantErr: ErrInvalidExchange},
+		{name: "above the balance", amount: "1000.01", currency: "USD", target: "EUR", wantErr: ErrInsufficientFunds},
+	}
+
+	for _, tt := range tests {
+		db := &memDB{rates: rates}
+		u := newTestTransactionUseCase(db)
+		userID := db.addUser()
+		db.deposit(userID, money.MustParse("1000"), tt.currency)
+
+		transaction, err := u.CreateExchange(context.Background(), &CreateExchangeRequest{
+			UserID:         userID,
+			Amount:         money.MustParse(tt.amount),
+			Currency:       tt.currency,
+			TargetCurrency: tt.target,
+		})
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: CreateExchange error = %v, want %v", tt.name, err, tt.wantErr)
+			}
+			if len(db.transactions) != 0 || len(db.published) != 0 {
+				t.Errorf("%s: stored %d transactions and %d events, want none", tt.name, len(db.transactions), len(db.published))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: CreateExchange error = %v", tt.name, err)
+			continue
+		}
+
+		if transaction.ExchangeRate == nil || *transaction.ExchangeRate != mustRate(t, tt.wantRate) {
+			t.Errorf("%s: rate = %v, want %s", tt.name, transaction.ExchangeRate, tt.wantRate)
+		}
+		if transaction.TargetAmount == nil || transaction.TargetAmount.Cmp(money.MustParse(tt.wantTarget)) != 0 {
+			t.Errorf("%s: target amount = %v, want %s", tt.name, transaction.TargetAmount, tt.wantTarget)
+		}
+
+		wantSource, _ := money.MustParse("1000").Sub(money.MustParse(tt.amount))
+		if got := db.balance(userID, tt.currency); got.Cmp(wantSource) != 0 {
+			t.Errorf("%s: %s balance = %s, want %s", tt.name, tt.currency, got, wantSource)
+		}
+		if got := db.balance(userID, tt.target); got.Cmp(money.MustParse(tt.wantTarget)) != 0 {
+			t.Errorf("%s: %s balance = %s, want %s", tt.name, tt.target, got, tt.wantTarget)
+		}
+	}
+}
+
+func TestSummarizeTransactions(t *testing.T) {
+	now := time.Now()
+	db := &memDB{rates: []entity.FXRate{
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: mustRate(t, "1.1"), EffectiveAt: now.Add(-48 * time.Hour)},
+		// Takes effect after the summary's rate time, so it is not used.
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: mustRate(t, "2"), EffectiveAt: now.Add(-time.Hour)},
+		{BaseCurrency: "USD", QuoteCurrency: "JPY", Rate: mustRate(t, "150"), EffectiveAt: now.Add(-48 * time.Hour)},
+	}}
+	u := newTestTransactionUseCase(db)
+	userID := db.addUser()
+	for _, transaction := range []*entity.Transaction{
+		newTestTransaction(userID, entity.TransactionTypeDeposit, entity.TransactionStatusSuccess, "10", "USD"),
+		newTestTransaction(userID, entity.TransactionTypeDeposit, entity.TransactionStatusSuccess, "5", "USD"),
+		newTestTransaction(userID, entity.TransactionTypeDeposit, entity.TransactionStatusSuccess, "20", "EUR"),
+		newTestTransaction(userID, entity.TransactionTypePurchase, entity.TransactionStatusSuccess, "1000", "JPY"),
+	} {
+		db.addTransaction(transaction)
+	}
+
+	at := now.Add(-24 * time.Hour)
+	summary, err := u.SummarizeTransactions(context.Background(), repository.TransactionFilter{}, "USD", at)
+	if err != nil {
+		t.Fatalf("SummarizeTransactions error = %v", err)
+	}
+
+	wantTotals := []struct {
+		transactionType, currency string
+		count                     int64
+		amount, converted         string
+	}{
+		{transactionType: entity.TransactionTypeDeposit, currency: "EUR", count: 1, amount: "20", converted: "22"},
+		{transactionType: entity.TransactionTypeDeposit, currency: "USD", count: 2, amount: "15", converted: "15"},
+		// Converted at the inverse of USD/JPY, 0.0066666667, and rounded to
+		// cents.
+		{transactionType: entity.TransactionTypePurchase, currency: "JPY", count: 1, amount: "1000", converted: "6.67"},
+	}
+	if len(summary.Totals) != len(wantTotals) {
+		t.Fatalf("summary has %d totals, want %d", len(summary.Totals), len(wantTotals))
+	}
+	for i, want := range wantTotals {
+		got := summary.Totals[i]
+		if got.TransactionType != want.transactionType || got.Currency != want.currency || got.Count != want.count ||
+			got.Amount.Cmp(money.MustParse(want.amount)) != 0 || got.ConvertedAmount.Cmp(money.MustParse(want.converted)) != 0 {
+			t.Errorf("total %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	wantByType := map[string]string{
+		entity.TransactionTypeDeposit:  "37",
+		entity.TransactionTypePurchase: "6.67",
+	}
+	if len(summary.ConvertedByType) != len(wantByType) {
+		t.Errorf("converted by type = %v, want %v", summary.ConvertedByType, wantByType)
+	}
+	for transactionType, want := range wantByType {
+		if got := summary.ConvertedByType[transactionType]; got.Cmp(money.MustParse(want)) != 0 {
+			t.Errorf("converted %s total = %s, want %s", transactionType, got, want)
+		}
+	}
+	if summary.BaseCurrency != "USD" || !summary.RatesAt.Equal(at) {
+		t.Errorf("summary in %s at %s, want USD at %s", summary.BaseCurrency, summary.RatesAt, at)
+	}
+
+	if _, err := u.SummarizeTransactions(context.Background(), repository.TransactionFilter{}, "CHF", at); !errors.Is(err, ErrNoExchangeRate) {
+		t.Errorf("summary in CHF error = %v, want ErrNoExchangeRate", err)
+	}
+	if _, err := u.SummarizeTransactions(context.Background(), repository.TransactionFilter{}, "XYZ", at); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("summary in XYZ error = %v, want ErrInvalidFilter", err)
+	}
+}
+
+func mustRate(t *testing.T, s string) money.Rate {
+	t.Helper()
+	rate, err := money.ParseRate(s)
+	if err != nil {
+		t.Fatalf("ParseRate(%q) error = %v", s, err)
+	}
+	return rate
+}
+
+func TestTransactionHistory(t *testing.T) {
+	db := &memDB{}
+	u := newTestTransactionUseCase(db)
+	ctx := events.WithCorrelationID(context.Background(), "req-1")
+	owner, admin, stranger := db.addUser(), db.addUser(), db.addUser()
+
+	transaction, err := u.CreateTransaction(ctx, &CreateTransactionRequest{
+		UserID:          owner,
+		Amount:          money.MustParse("20"),
+		Currency:        "USD",
+		TransactionType: entity.TransactionTypeDeposit,
+	})
+	if err != nil {
+		t.Fatalf("CreateTransaction error = %v", err)
+	}
+
+	steps := []struct {
+		actorID uuid.UUID
+		isAdmin bool
+		status  string
+		reason  string
+		wantErr bool
+	}{
+		{actorID: admin, isAdmin: true, status: entity.TransactionStatusProcessing},
+		// Rejected changes leave no trace.
+		{actorID: owner, status: entity.TransactionStatusCancelled, reason: entity.StatusReasonUserRequested, wantErr: true},
+		{actorID: admin, isAdmin: true, status: entity.TransactionStatusPending, wantErr: true},
+		{actorID: admin, isAdmin: true, status: entity.TransactionStatusSuccess},
+		{actorID: admin, isAdmin: true, status: entity.TransactionStatusReversed, reason: entity.StatusReasonChargeback},
+	}
+	for i, step := range steps {
+		_, err := u.UpdateTransactionStatus(ctx, transaction.ID, step.actorID, step.isAdmin, step.status, step.reason)
+		if step.wantErr != (err != nil) {
+			t.Fatalf("step %d to %s: error = %v, want error %t", i, step.status, err, step.wantErr)
+		}
+	}
+
+	history, err := u.GetTransactionHistory(ctx, transaction.ID, owner, false)
+	if err != nil {
+		t.Fatalf("GetTransactionHistory error = %v", err)
+	}
+
+	pending, processing, success := entity.TransactionStatusPending, entity.TransactionStatusProcessing, entity.TransactionStatusSuccess
+	want := []struct {
+		previous *string
+		status   string
+		actorID  uuid.UUID
+		reason   string
+	}{
+		{previous: nil, status: pending, actorID: owner},
+		{previous: &pending, status: processing, actorID: admin},
+		{previous: &processing, status: success, actorID: admin},
+		{previous: &success, status: entity.TransactionStatusReversed, actorID: admin, reason: entity.StatusReasonChargeback},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("history has %d rows, want %d", len(history), len(want))
+	}
+	for i, w := range want {
+		got := history[i]
+		if (got.PreviousStatus == nil) != (w.previous == nil) || (w.previous != nil && *got.PreviousStatus != *w.previous) {
+			t.Errorf("row %d previous status = %v, want %v", i, got.PreviousStatus, w.previous)
+		}
+		if got.Status != w.status {
+			t.Errorf("row %d status = %s, want %s", i, got.Status, w.status)
+		}
+		if got.ActorID == nil || *got.ActorID != w.actorID {
+			t.Errorf("row %d actor = %v, want %s", i, got.ActorID, w.actorID)
+		}
+		reason := ""
+		if got.Reason != nil {
+			reason = *got.Reason
+		}
+		if reason != w.reason {
+			t.Errorf("row %d reason = %q, want %q", i, reason, w.reason)
+		}
+		if got.RequestID == nil || *got.RequestID != "req-1" {
+			t.Errorf("row %d request ID = %v, want req-1", i, got.RequestID)
+		}
+	}
+
+	if _, err := u.GetTransactionHistory(ctx, transaction.ID, stranger, false); !errors.Is(err, repository.ErrTransactionNotFound) {
+		t.Errorf("GetTransactionHistory by another user error = %v, want ErrTransactionNotFound", err)
+	}
+	if history, err := u.GetTransactionHistory(ctx, transaction.ID, admin, true); err != nil || len(history) != len(want) {
+		t.Errorf("GetTransactionHistory by an admin = %d rows, %v, want %d rows", len(history), err, len(want))
+	}
+}
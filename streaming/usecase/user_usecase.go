@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserUseCase applies user events from the authentication service to the
+// local user projection.
+type UserUseCase interface {
+	SyncUser(ctx context.Context, id uuid.UUID, email, name string, eventAt time.Time) error
+	DeleteUser(ctx context.Context, id uuid.UUID, eventAt time.Time) error
+}
+
+type userUseCase struct {
+	repo repository.UserProjectionRepository
+}
+
+func NewUserUseCase(repo repository.UserProjectionRepository) UserUseCase {
+	return &userUseCase{
+		repo: repo,
+	}
+}
+
+func (u *userUseCase) SyncUser(ctx context.Context, id uuid.UUID, email, name string, eventAt time.Time) error {
+	return u.repo.Upsert(ctx, &entity.UserProjection{
+		ID:          id,
+		Email:       email,
+		Name:        name,
+		Deleted:     false,
+		LastEventAt: eventAt,
+		UpdatedAt:   time.Now(),
+	})
+}
+
+func (u *userUseCase) DeleteUser(ctx context.Context, id uuid.UUID, eventAt time.Time) error {
+	return u.repo.MarkDeleted(ctx, id, eventAt)
+}
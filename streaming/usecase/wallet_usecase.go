@@ -0,0 +1,330 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/money"
+	"go-api-streaming/domain/repository"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInsufficientFunds is returned when a withdrawal, purchase or reversal
+// exceeds the wallet's available balance.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// WalletUseCase keeps users' wallets in a double-entry ledger. ReserveFunds
+// and PostTransaction must run inside the database transaction that creates
+// or settles the transaction, so the check and the change commit together.
+type WalletUseCase interface {
+	GetWallets(ctx context.Context, userID uuid.UUID) ([]*entity.Wallet, error)
+	// ReserveFunds checks that a new withdrawal, purchase or reversal fits in
+	// the wallet's available balance. Other transaction types always fit.
+	ReserveFunds(ctx context.Context, transaction *entity.Transaction) error
+	// PostTransaction posts the balanced ledger entries of a successful
+	// transaction. Posting the same transaction twice is a no-op.
+	PostTransaction(ctx context.Context, transaction *entity.Transaction) error
+	// ReverseTransaction posts entries mirroring those of a reversed
+	// transaction. Transactions that were never posted, or were already
+	// reversed, are left alone.
+	ReverseTransaction(ctx context.Context, transaction *entity.Transaction) error
+	// PostTransfer posts both legs of a transfer if the sender's available
+	// balance covers it.
+	PostTransfer(ctx context.Context, transfer *entity.Transfer) error
+	// PostExchange posts a currency exchange if the available balance of the
+	// source currency covers it.
+	PostExchange(ctx context.Context, transaction *entity.Transaction) error
+}
+
+type walletUseCase struct {
+	ledgerRepo repository.LedgerRepository
+}
+
+func NewWalletUseCase(ledgerRepo repository.LedgerRepository) WalletUseCase {
+	return &walletUseCase{
+		ledgerRepo: ledgerRepo,
+	}
+}
+
+func (u *walletUseCase) GetWallets(ctx context.Context, userID uuid.UUID) ([]*entity.Wallet, error) {
+	wallets, err := u.ledgerRepo.ListWallets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, wallet := range wallets {
+		wallet.Available, err = wallet.Balance.Sub(wallet.Reserved)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return wallets, nil
+}
+
+func (u *walletUseCase) ReserveFunds(ctx context.Context, transaction *entity.Transaction) error {
+	if !isOutflow(transaction.TransactionType) {
+		return nil
+	}
+
+	// Locking the wallet makes concurrent reservations wait for each other,
+	// so they cannot both pass the check against the same balance.
+	wallet, err := u.ledgerRepo.LockWallet(ctx, transaction.UserID, transaction.Currency)
+	if err != nil {
+		return err
+	}
+
+	balance, err := u.ledgerRepo.Balance(ctx, wallet.ID)
+	if err != nil {
+		return err
+	}
+
+	reserved, err := u.ledgerRepo.ReservedAmount(ctx, transaction.UserID, transaction.Currency)
+	if err != nil {
+		return err
+	}
+
+	available, err := balance.Sub(reserved)
+	if err != nil {
+		return err
+	}
+	if transaction.Amount.Cmp(available) > 0 {
+		return fmt.Errorf("%w: %s %s available", ErrInsufficientFunds, available, transaction.Currency)
+	}
+
+	return nil
+}
+
+func (u *walletUseCase) PostTransaction(ctx context.Context, transaction *entity.Transaction) error {
+	wallet, err := u.ledgerRepo.LockWallet(ctx, transaction.UserID, transaction.Currency)
+	if err != nil {
+		return err
+	}
+
+	posted, err := u.ledgerRepo.HasEntries(ctx, transaction.ID)
+	if err != nil {
+		return err
+	}
+	if posted {
+		return nil
+	}
+
+	var counterpartName string
+	switch transaction.TransactionType {
+	case entity.TransactionTypeDeposit, entity.TransactionTypeWithdraw, entity.TransactionTypeReversal:
+		counterpartName = entity.LedgerAccountExternal
+	case entity.TransactionTypePurchase, entity.TransactionTypeRefund:
+		counterpartName = entity.LedgerAccountRevenue
+	case entity.TransactionTypeTransferOut, entity.TransactionTypeTransferIn:
+		counterpartName = entity.LedgerAccountTransfers
+	default:
+		return fmt.Errorf("cannot post transaction type: %s", transaction.TransactionType)
+	}
+
+	counterpart, err := u.ledgerRepo.GetSystemAccount(ctx, counterpartName, transaction.Currency)
+	if err != nil {
+		return err
+	}
+
+	// Money leaving the wallet is a debit to it; money arriving is a credit.
+	debit, credit := counterpart, wallet
+	if isOutflow(transaction.TransactionType) {
+		balance, err := u.ledgerRepo.Balance(ctx, wallet.ID)
+		if err != nil {
+			return err
+		}
+		if transaction.Amount.Cmp(balance) > 0 {
+			return fmt.Errorf("%w: %s %s in wallet", ErrInsufficientFunds, balance, transaction.Currency)
+		}
+		debit, credit = wallet, counterpart
+	}
+
+	now := time.Now()
+	return u.ledgerRepo.CreateEntries(ctx, []*entity.LedgerEntry{
+		{
+			TransactionID: transaction.ID,
+			AccountID:     debit.ID,
+			Direction:     entity.LedgerDebit,
+			Amount:        transaction.Amount,
+			Currency:      transaction.Currency,
+			CreatedAt:     now,
+		},
+		{
+			TransactionID: transaction.ID,
+			AccountID:     credit.ID,
+			Direction:     entity.LedgerCredit,
+			Amount:        transaction.Amount,
+			Currency:      transaction.Currency,
+			CreatedAt:     now,
+		},
+	})
+}
+
+func (u *walletUseCase) ReverseTransaction(ctx context.Context, transaction *entity.Transaction) error {
+	entries, err := u.ledgerRepo.ListEntries(ctx, transaction.ID)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.Reversal {
+			return nil
+		}
+	}
+
+	// An exchange touches a wallet per currency. Lock them in the same
+	// sorted order PostExchange does, so the two cannot deadlock.
+	var currencies []string
+	for _, entry := range entries {
+		if !containsValue(currencies, entry.Currency) {
+			currencies = append(currencies, entry.Currency)
+		}
+	}
+	sort.Strings(currencies)
+	wallets := make(map[string]*entity.LedgerAccount, len(currencies))
+	for _, currency := range currencies {
+		wallet, err := u.ledgerRepo.LockWallet(ctx, transaction.UserID, currency)
+		if err != nil {
+			return err
+		}
+		wallets[currency] = wallet
+	}
+
+	// Reversing money that arrived in a wallet takes it back out, so the
+	// wallet must still hold it on top of what pending transactions have
+	// reserved, as ReserveFunds checks.
+	for _, entry := range entries {
+		wallet := wallets[entry.Currency]
+		if entry.AccountID != wallet.ID || entry.Direction != entity.LedgerCredit {
+			continue
+		}
+
+		balance, err := u.ledgerRepo.Balance(ctx, wallet.ID)
+		if err != nil {
+			return err
+		}
+		reserved, err := u.ledgerRepo.ReservedAmount(ctx, transaction.UserID, entry.Currency)
+		if err != nil {
+			return err
+		}
+		available, err := balance.Sub(reserved)
+		if err != nil {
+			return err
+		}
+		if entry.Amount.Cmp(available) > 0 {
+			return fmt.Errorf("%w: %s %s available", ErrInsufficientFunds, available, entry.Currency)
+		}
+	}
+
+	now := time.Now()
+	reversals := make([]*entity.LedgerEntry, 0, len(entries))
+	for _, entry := range entries {
+		direction := entity.LedgerDebit
+		if entry.Direction == entity.LedgerDebit {
+			direction = entity.LedgerCredit
+		}
+		reversals = append(reversals, &entity.LedgerEntry{
+			TransactionID: entry.TransactionID,
+			AccountID:     entry.AccountID,
+			Direction:     direction,
+			Amount:        entry.Amount,
+			Currency:      entry.Currency,
+			Reversal:      true,
+			CreatedAt:     now,
+		})
+	}
+
+	return u.ledgerRepo.CreateEntries(ctx, reversals)
+}
+
+func (u *walletUseCase) PostTransfer(ctx context.Context, transfer *entity.Transfer) error {
+	// Lock both wallets in a fixed order, so transfers in opposite directions
+	// between the same users cannot deadlock.
+	first, second := transfer.Outgoing, transfer.Incoming
+	if bytes.Compare(second.UserID[:], first.UserID[:]) < 0 {
+		first, second = second, first
+	}
+	for _, leg := range []*entity.Transaction{first, second} {
+		if _, err := u.ledgerRepo.LockWallet(ctx, leg.UserID, leg.Currency); err != nil {
+			return err
+		}
+	}
+
+	if err := u.ReserveFunds(ctx, transfer.Outgoing); err != nil {
+		return err
+	}
+	if err := u.PostTransaction(ctx, transfer.Outgoing); err != nil {
+		return err
+	}
+	return u.PostTransaction(ctx, transfer.Incoming)
+}
+
+func (u *walletUseCase) PostExchange(ctx context.Context, transaction *entity.Transaction) error {
+	if transaction.TargetAmount == nil || transaction.TargetCurrency == nil {
+		return fmt.Errorf("exchange %s has no target amount", transaction.ID)
+	}
+	targetAmount, targetCurrency := *transaction.TargetAmount, *transaction.TargetCurrency
+
+	// Lock both wallets in a fixed order, like transfers do.
+	currencies := []string{transaction.Currency, targetCurrency}
+	if targetCurrency < transaction.Currency {
+		currencies[0], currencies[1] = currencies[1], currencies[0]
+	}
+	wallets := make(map[string]*entity.LedgerAccount, len(currencies))
+	for _, currency := range currencies {
+		wallet, err := u.ledgerRepo.LockWallet(ctx, transaction.UserID, currency)
+		if err != nil {
+			return err
+		}
+		wallets[currency] = wallet
+	}
+
+	if err := u.ReserveFunds(ctx, transaction); err != nil {
+		return err
+	}
+
+	source, err := u.ledgerRepo.GetSystemAccount(ctx, entity.LedgerAccountExchange, transaction.Currency)
+	if err != nil {
+		return err
+	}
+	target, err := u.ledgerRepo.GetSystemAccount(ctx, entity.LedgerAccountExchange, targetCurrency)
+	if err != nil {
+		return err
+	}
+
+	// Each currency balances on its own: the source currency moves from the
+	// wallet to its exchange account, the target currency the other way.
+	now := time.Now()
+	entry := func(account *entity.LedgerAccount, direction string, amount money.Amount, currency string) *entity.LedgerEntry {
+		return &entity.LedgerEntry{
+			TransactionID: transaction.ID,
+			AccountID:     account.ID,
+			Direction:     direction,
+			Amount:        amount,
+			Currency:      currency,
+			CreatedAt:     now,
+		}
+	}
+	return u.ledgerRepo.CreateEntries(ctx, []*entity.LedgerEntry{
+		entry(wallets[transaction.Currency], entity.LedgerDebit, transaction.Amount, transaction.Currency),
+		entry(source, entity.LedgerCredit, transaction.Amount, transaction.Currency),
+		entry(target, entity.LedgerDebit, targetAmount, targetCurrency),
+		entry(wallets[targetCurrency], entity.LedgerCredit, targetAmount, targetCurrency),
+	})
+}
+
+// isOutflow reports whether a transaction type takes money out of the wallet.
+func isOutflow(transactionType string) bool {
+	return transactionType == entity.TransactionTypeWithdraw ||
+		transactionType == entity.TransactionTypePurchase ||
+		transactionType == entity.TransactionTypeReversal ||
+		transactionType == entity.TransactionTypeTransferOut ||
+		transactionType == entity.TransactionTypeExchange
+}
@@ -0,0 +1,277 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/money"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestReserveFunds(t *testing.T) {
+	db := &memDB{}
+	wallet := NewWalletUseCase(memLedgerRepo{db})
+	userID := db.addUser()
+	db.deposit(userID, money.MustParse("100"), "USD")
+
+	// A pending withdrawal holds 30 of the 100.
+	db.addTransaction(newTestTransaction(userID, entity.TransactionTypeWithdraw, entity.TransactionStatusPending, "30", "USD"))
+
+	tests := []struct {
+		name            string
+		transactionType string
+		amount          string
+		currency        string
+		wantErr         bool
+	}{
+		{name: "withdrawal of the available balance", transactionType: entity.TransactionTypeWithdraw, amount: "70"},
+		{name: "withdrawal above the available balance", transactionType: entity.TransactionTypeWithdraw, amount: "70.0001", wantErr: true},
+		{name: "purchase above the available balance", transactionType: entity.TransactionTypePurchase, amount: "80", wantErr: true},
+		{name: "withdrawal from an empty wallet", transactionType: entity.TransactionTypeWithdraw, amount: "1", currency: "EUR", wantErr: true},
+		{name: "deposit", transactionType: entity.TransactionTypeDeposit, amount: "1000"},
+		{name: "refund", transactionType: entity.TransactionTypeRefund, amount: "1000"},
+	}
+
+	for _, tt := range tests {
+		currency := tt.currency
+		if currency == "" {
+			currency = "USD"
+		}
+		transaction := newTestTransaction(userID, tt.transactionType, entity.TransactionStatusPending, tt.amount, currency)
+		err := wallet.ReserveFunds(context.Background(), transaction)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInsufficientFunds) {
+				t.Errorf("%s: error = %v, want ErrInsufficientFunds", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+	}
+}
+
+func TestPostTransaction(t *testing.T) {
+	db := &memDB{}
+	wallet := NewWalletUseCase(memLedgerRepo{db})
+	ctx := context.Background()
+	userID := db.addUser()
+
+	deposit := newTestTransaction(userID, entity.TransactionTypeDeposit, entity.TransactionStatusSuccess, "50", "USD")
+	if err := wallet.PostTransaction(ctx, deposit); err != nil {
+		t.Fatalf("PostTransaction(deposit) error = %v", err)
+	}
+	// Posting the same transaction again changes nothing.
+	if err := wallet.PostTransaction(ctx, deposit); err != nil {
+		t.Fatalf("PostTransaction(deposit) again error = %v", err)
+	}
+	if got := db.balance(userID, "USD"); got.Cmp(money.MustParse("50")) != 0 {
+		t.Errorf("balance after deposit = %s, want 50", got)
+	}
+	assertBalanced(t, db)
+
+	purchase := newTestTransaction(userID, entity.TransactionTypePurchase, entity.TransactionStatusSuccess, "20", "USD")
+	if err := wallet.PostTransaction(ctx, purchase); err != nil {
+		t.Fatalf("PostTransaction(purchase) error = %v", err)
+	}
+	if got := db.balance(userID, "USD"); got.Cmp(money.MustParse("30")) != 0 {
+		t.Errorf("balance after purchase = %s, want 30", got)
+	}
+
+	withdrawal := newTestTransaction(userID, entity.TransactionTypeWithdraw, entity.TransactionStatusSuccess, "30.0001", "USD")
+	if err := wallet.PostTransaction(ctx, withdrawal); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("PostTransaction(withdrawal above balance) error = %v, want ErrInsufficientFunds", err)
+	}
+	if got := db.balance(userID, "USD"); got.Cmp(money.MustParse("30")) != 0 {
+		t.Errorf("balance after failed withdrawal = %s, want 30", got)
+	}
+	assertBalanced(t, db)
+}
+
+func TestPostTransfer(t *testing.T) {
+	db := &memDB{}
+	wallet := NewWalletUseCase(memLedgerRepo{db})
+	ctx := context.Background()
+	sender, recipient := db.addUser(), db.addUser()
+	db.deposit(sender, money.MustParse("100"), "USD")
+	db.addTransaction(newTestTransaction(sender, entity.TransactionTypePurchase, entity.TransactionStatusPending, "40", "USD"))
+
+	transfer := func(amount string) *entity.Transfer {
+		return &entity.Transfer{
+			ID:       uuid.New(),
+			Outgoing: newTestTransaction(sender, entity.TransactionTypeTransferOut, entity.TransactionStatusSuccess, amount, "USD"),
+			Incoming: newTestTransaction(recipient, entity.TransactionTypeTransferIn, entity.TransactionStatusSuccess, amount, "USD"),
+		}
+	}
+
+	// 40 of the sender's 100 is reserved by the pending purchase.
+	if err := wallet.PostTransfer(ctx, transfer("60.0001")); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("PostTransfer above the available balance error = %v, want ErrInsufficientFunds", err)
+	}
+	if err := wallet.PostTransfer(ctx, transfer("60")); err != nil {
+		t.Fatalf("PostTransfer error = %v", err)
+	}
+
+	if got := db.balance(sender, "USD"); got.Cmp(money.MustParse("40")) != 0 {
+		t.Errorf("sender balance = %s, want 40", got)
+	}
+	if got := db.balance(recipient, "USD"); got.Cmp(money.MustParse("60")) != 0 {
+		t.Errorf("recipient balance = %s, want 60", got)
+	}
+	assertBalanced(t, db)
+}
+
+func TestPostExchange(t *testing.T) {
+	db := &memDB{}
+	wallet := NewWalletUseCase(memLedgerRepo{db})
+	ctx := context.Background()
+	userID := db.addUser()
+	db.deposit(userID, money.MustParse("100"), "USD")
+
+	exchange := func(amount, targetAmount string) *entity.Transaction {
+		transaction := newTestTransaction(userID, entity.TransactionTypeExchange, entity.TransactionStatusSuccess, amount, "USD")
+		target, currency := money.MustParse(targetAmount), "EUR"
+		transaction.TargetAmount, transaction.TargetCurrency = &target, &currency
+		return transaction
+	}
+
+	if err := wallet.PostExchange(ctx, exchange("100.0001", "92")); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("PostExchange above the balance error = %v, want ErrInsufficientFunds", err)
+	}
+	if err := wallet.PostExchange(ctx, exchange("25", "23.13")); err != nil {
+		t.Fatalf("PostExchange error = %v", err)
+	}
+
+	if got := db.balance(userID, "USD"); got.Cmp(money.MustParse("75")) != 0 {
+		t.Errorf("USD balance = %s, want 75", got)
+	}
+	if got := db.balance(userID, "EUR"); got.Cmp(money.MustParse("23.13")) != 0 {
+		t.Errorf("EUR balance = %s, want 23.13", got)
+	}
+	assertBalanced(t, db)
+}
+
+func TestReverseTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("deposit", func(t *testing.T) {
+		db := &memDB{}
+		wallet := NewWalletUseCase(memLedgerRepo{db})
+		userID := db.addUser()
+
+		deposit := newTestTransaction(userID, entity.TransactionTypeDeposit, entity.TransactionStatusSuccess, "50", "USD")
+		if err := wallet.PostTransaction(ctx, deposit); err != nil {
+			t.Fatalf("PostTransaction error = %v", err)
+		}
+		if err := wallet.ReverseTransaction(ctx, deposit); err != nil {
+			t.Fatalf("ReverseTransaction error = %v", err)
+		}
+		// Reversing twice changes nothing.
+		if err := wallet.ReverseTransaction(ctx, deposit); err != nil {
+			t.Fatalf("ReverseTransaction again error = %v", err)
+		}
+
+		if got := db.balance(userID, "USD"); !got.IsZero() {
+			t.Errorf("balance = %s, want 0", got)
+		}
+		if len(db.entries) != 4 {
+			t.Errorf("ledger has %d entries, want 2 postings and 2 reversals", len(db.entries))
+		}
+		assertBalanced(t, db)
+	})
+
+	t.Run("reserved funds", func(t *testing.T) {
+		db := &memDB{}
+		wallet := NewWalletUseCase(memLedgerRepo{db})
+		userID := db.addUser()
+
+		deposit := newTestTransaction(userID, entity.TransactionTypeDeposit, entity.TransactionStatusSuccess, "50", "USD")
+		if err := wallet.PostTransaction(ctx, deposit); err != nil {
+			t.Fatalf("PostTransaction error = %v", err)
+		}
+		// The deposit is still in the wallet, but a pending withdrawal
+		// holds part of it.
+		db.addTransaction(newTestTransaction(userID, entity.TransactionTypeWithdraw, entity.TransactionStatusPending, "0.0001", "USD"))
+
+		if err := wallet.ReverseTransaction(ctx, deposit); !errors.Is(err, ErrInsufficientFunds) {
+			t.Errorf("ReverseTransaction error = %v, want ErrInsufficientFunds", err)
+		}
+		if len(db.entries) != 2 {
+			t.Errorf("ledger has %d entries, want the 2 of the deposit", len(db.entries))
+		}
+	})
+
+	t.Run("exchange", func(t *testing.T) {
+		db := &memDB{}
+		wallet := NewWalletUseCase(memLedgerRepo{db})
+		userID := db.addUser()
+		db.deposit(userID, money.MustParse("10"), "USD")
+
+		exchange := newTestTransaction(userID, entity.TransactionTypeExchange, entity.TransactionStatusSuccess, "10", "USD")
+		target, currency := money.MustParse("1500"), "JPY"
+		exchange.TargetAmount, exchange.TargetCurrency = &target, &currency
+		if err := wallet.PostExchange(ctx, exchange); err != nil {
+			t.Fatalf("PostExchange error = %v", err)
+		}
+		if err := wallet.ReverseTransaction(ctx, exchange); err != nil {
+			t.Fatalf("ReverseTransaction error = %v", err)
+		}
+
+		if got := db.balance(userID, "USD"); got.Cmp(money.MustParse("10")) != 0 {
+			t.Errorf("USD balance = %s, want 10", got)
+		}
+		if got := db.balance(userID, "JPY"); !got.IsZero() {
+			t.Errorf("JPY balance = %s, want 0", got)
+		}
+		assertBalanced(t, db)
+	})
+
+	t.Run("never posted", func(t *testing.T) {
+		db := &memDB{}
+		wallet := NewWalletUseCase(memLedgerRepo{db})
+
+		pending := newTestTransaction(db.addUser(), entity.TransactionTypeDeposit, entity.TransactionStatusPending, "50", "USD")
+		if err := wallet.ReverseTransaction(ctx, pending); err != nil {
+			t.Fatalf("ReverseTransaction error = %v", err)
+		}
+		if len(db.entries) != 0 {
+			t.Errorf("ledger has %d entries, want none", len(db.entries))
+		}
+	})
+}
+
+func newTestTransaction(userID uuid.UUID, transactionType, status, amount, currency string) *entity.Transaction {
+	now := time.Now()
+	return &entity.Transaction{
+		ID:              uuid.New(),
+		UserID:          userID,
+		Amount:          money.MustParse(amount),
+		Currency:        currency,
+		TransactionType: transactionType,
+		Status:          status,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+// assertBalanced checks that the accounts of every currency in the ledger sum
+// to zero.
+func assertBalanced(t *testing.T, db *memDB) {
+	t.Helper()
+	totals := make(map[string]money.Amount)
+	for _, entry := range db.entries {
+		amount := entry.Amount
+		if entry.Direction == entity.LedgerDebit {
+			amount = amount.Neg()
+		}
+		totals[entry.Currency], _ = totals[entry.Currency].Add(amount)
+	}
+	for currency, total := range totals {
+		if !total.IsZero() {
+			t.Errorf("%s ledger entries sum to %s, want 0", currency, total)
+		}
+	}
+}
@@ -0,0 +1,474 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/domain/repository"
+	"go-api-streaming/infrastructure/webhook"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidWebhook is wrapped by validation errors of webhook requests.
+var ErrInvalidWebhook = errors.New("invalid webhook subscription")
+
+// webhookEventTypes are the event types a subscription may ask for.
+var webhookEventTypes = []string{
+	events.TypeTransactionCreated,
+	events.TypeTransactionUpdated,
+}
+
+// WebhookUseCase manages users' webhook subscriptions and delivers
+// transaction events to them.
+type WebhookUseCase interface {
+	CreateSubscription(ctx context.Context, userID uuid.UUID, req *CreateWebhookRequest) (*entity.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]*entity.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id, userID uuid.UUID) (*entity.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, id, userID uuid.UUID, req *UpdateWebhookRequest) (*entity.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id, userID uuid.UUID) error
+	ListDeliveries(ctx context.Context, id, userID uuid.UUID, limit int) ([]*entity.WebhookDelivery, error)
+	ListAttempts(ctx context.Context, id, deliveryID, userID uuid.UUID) ([]*entity.WebhookDeliveryAttempt, error)
+	// Redeliver queues a delivery again with a fresh retry budget. Attempt
+	// numbers keep counting up from the earlier attempts.
+	Redeliver(ctx context.Context, id, deliveryID, userID uuid.UUID) (*entity.WebhookDelivery, error)
+
+	// EnqueueEvent queues a transaction event for every matching active
+	// subscription of the transaction's owner. Queuing the same event twice
+	// is a no-op.
+	EnqueueEvent(ctx context.Context, envelope *events.Envelope, body []byte) error
+	// DeliverDue sends up to limit due deliveries and returns how many were
+	// attempted.
+	DeliverDue(ctx context.Context, limit int) (int, error)
+}
+
+// WebhookPolicy controls retries and auto-disabling.
+type WebhookPolicy struct {
+	MaxAttempts       int
+	RetryInitialDelay time.Duration
+	RetryMaxDelay     time.Duration
+	// DisableAfter consecutive failed attempts disable a subscription.
+	DisableAfter int
+	// SendTimeout is the longest a single attempt takes. Claimed deliveries
+	// are leased for long enough to send the whole batch.
+	SendTimeout time.Duration
+}
+
+// deliveryLeaseMargin is added to a claimed batch's lease to cover recording
+// the results.
+const deliveryLeaseMargin = time.Minute
+
+type webhookUseCase struct {
+	repo      repository.WebhookRepository
+	txManager repository.TxManager
+	client    *webhook.Client
+	policy    WebhookPolicy
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"event_types" binding:"required"`
+	// Secret is generated when empty.
+	Secret string `json:"secret"`
+}
+
+// UpdateWebhookRequest changes the fields that are set. Re-activating a
+// disabled subscription resets its failure count.
+type UpdateWebhookRequest struct {
+	URL        *string  `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     *string  `json:"secret"`
+	Active     *bool    `json:"active"`
+}
+
+func NewWebhookUseCase(
+	repo repository.WebhookRepository,
+	txManager repository.TxManager,
+	client *webhook.Client,
+	policy WebhookPolicy,
+) WebhookUseCase {
+	return &webhookUseCase{
+		repo:      repo,
+		txManager: txManager,
+		client:    client,
+		policy:    policy,
+	}
+}
+
+func (u *webhookUseCase) CreateSubscription(ctx context.Context, userID uuid.UUID, req *CreateWebhookRequest) (*entity.WebhookSubscription, error) {
+	if err := u.validateWebhookURL(ctx, req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	} else if err := validateWebhookSecret(secret); err != nil {
+		return nil, err
+	}
+
+	subscription := &entity.WebhookSubscription{
+		ID:         uuid.New(),
+		UserID:     userID,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     secret,
+		Active:     true,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	if err := u.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (u *webhookUseCase) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]*entity.WebhookSubscription, error) {
+	return u.repo.ListSubscriptions(ctx, userID)
+}
+
+func (u *webhookUseCase) GetSubscription(ctx context.Context, id, userID uuid.UUID) (*entity.WebhookSubscription, error) {
+	subscription, err := u.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Other users' subscriptions are reported as missing.
+	if subscription.UserID != userID {
+		return nil, repository.ErrWebhookNotFound
+	}
+
+	return subscription, nil
+}
+
+func (u *webhookUseCase) UpdateSubscription(ctx context.Context, id, userID uuid.UUID, req *UpdateWebhookRequest) (*entity.WebhookSubscription, error) {
+	subscription, err := u.GetSubscription(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := u.validateWebhookURL(ctx, *req.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+			return nil, err
+		}
+		subscription.EventTypes = req.EventTypes
+	}
+	if req.Secret != nil {
+		if err := validateWebhookSecret(*req.Secret); err != nil {
+			return nil, err
+		}
+		subscription.Secret = *req.Secret
+	}
+	if req.Active != nil {
+		if *req.Active && !subscription.Active {
+			subscription.FailureCount = 0
+			subscription.DisabledAt = nil
+		}
+		subscription.Active = *req.Active
+	}
+	subscription.UpdatedAt = time.Now()
+
+	if err := u.repo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (u *webhookUseCase) DeleteSubscription(ctx context.Context, id, userID uuid.UUID) error {
+	if _, err := u.GetSubscription(ctx, id, userID); err != nil {
+		return err
+	}
+
+	return u.repo.DeleteSubscription(ctx, id)
+}
+
+func (u *webhookUseCase) ListDeliveries(ctx context.Context, id, userID uuid.UUID, limit int) ([]*entity.WebhookDelivery, error) {
+	if _, err := u.GetSubscription(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	return u.repo.ListDeliveries(ctx, id, clampLimit(limit))
+}
+
+func (u *webhookUseCase) ListAttempts(ctx context.Context, id, deliveryID, userID uuid.UUID) ([]*entity.WebhookDeliveryAttempt, error) {
+	if _, err := u.GetSubscription(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	if _, err := u.repo.GetDelivery(ctx, deliveryID, id); err != nil {
+		return nil, err
+	}
+
+	return u.repo.ListAttempts(ctx, deliveryID)
+}
+
+func (u *webhookUseCase) Redeliver(ctx context.Context, id, deliveryID, userID uuid.UUID) (*entity.WebhookDelivery, error) {
+	if _, err := u.GetSubscription(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := u.repo.GetDelivery(ctx, deliveryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Status = entity.WebhookDeliveryPending
+	delivery.RetryBase = delivery.Attempts
+	delivery.NextAttemptAt = time.Now()
+	delivery.UpdatedAt = time.Now()
+
+	if err := u.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (u *webhookUseCase) EnqueueEvent(ctx context.Context, envelope *events.Envelope, body []byte) error {
+	transaction, err := events.DecodeTransaction(envelope)
+	if err != nil {
+		return err
+	}
+
+	eventID, err := uuid.Parse(envelope.ID)
+	if err != nil {
+		return fmt.Errorf("invalid event ID %q: %w", envelope.ID, err)
+	}
+
+	subscriptions, err := u.repo.ListActiveSubscriptions(ctx, transaction.UserID, envelope.Type)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		delivery := &entity.WebhookDelivery{
+			ID:             uuid.New(),
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      envelope.Type,
+			Payload:        body,
+			Status:         entity.WebhookDeliveryPending,
+			NextAttemptAt:  time.Now(),
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}
+		if err := u.repo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeliverDue claims a batch of due deliveries by moving their next attempt
+// past a lease, then sends them outside of any database transaction, so no
+// row stays locked during the HTTP calls. A delivery whose result cannot be
+// recorded is retried once its lease expires.
+func (u *webhookUseCase) DeliverDue(ctx context.Context, limit int) (int, error) {
+	var deliveries []*entity.WebhookDelivery
+	err := u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		deliveries, err = u.repo.LockDueDeliveries(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		leasedUntil := time.Now().Add(time.Duration(len(deliveries))*u.policy.SendTimeout + deliveryLeaseMargin)
+		for _, delivery := range deliveries {
+			delivery.NextAttemptAt = leasedUntil
+			delivery.UpdatedAt = time.Now()
+			if err := u.repo.UpdateDelivery(ctx, delivery); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	attempted := 0
+	var errs []error
+	for _, delivery := range deliveries {
+		sent, err := u.deliver(ctx, delivery)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("delivery %s: %w", delivery.ID, err))
+			continue
+		}
+		if sent {
+			attempted++
+		}
+	}
+
+	return attempted, errors.Join(errs...)
+}
+
+// deliver makes one attempt on a claimed delivery and reports whether it was
+// made. The result is recorded in its own transaction, which logs the attempt
+// and schedules the next one if it failed.
+func (u *webhookUseCase) deliver(ctx context.Context, delivery *entity.WebhookDelivery) (bool, error) {
+	subscription, err := u.repo.GetSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return false, err
+	}
+
+	// A subscription disabled since the claim keeps its delivery pending,
+	// to resume once it is re-activated.
+	if !subscription.Active {
+		return false, nil
+	}
+
+	started := time.Now()
+	statusCode, sendErr := u.client.Send(ctx, webhook.Request{
+		URL:         subscription.URL,
+		Secret:      subscription.Secret,
+		DeliveryID:  delivery.ID.String(),
+		EventType:   delivery.EventType,
+		ContentType: events.ContentType,
+		Body:        delivery.Payload,
+	})
+
+	return true, u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		return u.recordAttempt(ctx, subscription, delivery, started, statusCode, sendErr)
+	})
+}
+
+// recordAttempt logs an attempt on a delivery and updates the delivery and
+// its subscription with the outcome.
+func (u *webhookUseCase) recordAttempt(
+	ctx context.Context,
+	subscription *entity.WebhookSubscription,
+	delivery *entity.WebhookDelivery,
+	started time.Time,
+	statusCode int,
+	sendErr error,
+) error {
+	delivery.Attempts++
+	delivery.UpdatedAt = time.Now()
+
+	attempt := &entity.WebhookDeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		DurationMs: int(time.Since(started).Milliseconds()),
+		CreatedAt:  started,
+	}
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+	if sendErr != nil {
+		reason := sendErr.Error()
+		attempt.Error = &reason
+		delivery.LastError = &reason
+	}
+	if err := u.repo.CreateAttempt(ctx, attempt); err != nil {
+		return err
+	}
+
+	if sendErr == nil {
+		delivery.Status = entity.WebhookDeliverySucceeded
+		delivery.LastError = nil
+		if err := u.repo.UpdateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+		return u.repo.RecordSubscriptionSuccess(ctx, subscription.ID)
+	}
+
+	// Attempts made before the delivery was last redelivered do not count
+	// against its retry budget.
+	attempts := delivery.Attempts - delivery.RetryBase
+	if attempts >= u.policy.MaxAttempts {
+		delivery.Status = entity.WebhookDeliveryFailed
+		log.Printf("Webhook delivery %s to %s failed after %d attempts: %v", delivery.ID, subscription.URL, attempts, sendErr)
+	} else {
+		delivery.NextAttemptAt = time.Now().Add(u.retryDelay(attempts))
+	}
+	if err := u.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return err
+	}
+
+	disabled, err := u.repo.RecordSubscriptionFailure(ctx, subscription.ID, u.policy.DisableAfter)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		log.Printf("Webhook subscription %s disabled after %d consecutive failures", subscription.ID, u.policy.DisableAfter)
+	}
+
+	return nil
+}
+
+// retryDelay doubles the initial delay for each failed attempt, up to the
+// maximum.
+func (u *webhookUseCase) retryDelay(attempts int) time.Duration {
+	delay := u.policy.RetryInitialDelay
+	for i := 1; i < attempts && delay < u.policy.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > u.policy.RetryMaxDelay {
+		delay = u.policy.RetryMaxDelay
+	}
+	return delay
+}
+
+// validateWebhookURL checks that raw is an absolute http or https URL whose
+// host the client is allowed to call.
+func (u *webhookUseCase) validateWebhookURL(ctx context.Context, raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if err := u.client.CheckHost(ctx, parsed.Hostname()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	return nil
+}
+
+func validateWebhookEventTypes(eventTypes []string) error {
+	if len(eventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", ErrInvalidWebhook)
+	}
+	for _, eventType := range eventTypes {
+		if !contains(webhookEventTypes, eventType) {
+			return fmt.Errorf("%w: unknown event type %s", ErrInvalidWebhook, eventType)
+		}
+	}
+	return nil
+}
+
+func validateWebhookSecret(secret string) error {
+	if len(secret) < 16 || len(secret) > 100 {
+		return fmt.Errorf("%w: secret must be between 16 and 100 characters", ErrInvalidWebhook)
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,119 @@
+package worker
+
+import (
+	"context"
+	"go-api-streaming/usecase"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// changeFeedChannel is the NOTIFY channel used by the transactions trigger.
+const changeFeedChannel = "transaction_changes"
+
+// Bounds of the backoff between attempts to start listening.
+const (
+	listenRetryMinDelay = time.Second
+	listenRetryMaxDelay = time.Minute
+)
+
+// ChangeFeedListener listens for NOTIFY from the transactions trigger and
+// emits events for rows changed outside the service. The trigger also stores
+// every change in transaction_change_feed, so changes made while no listener
+// was connected are picked up on the next poll.
+type ChangeFeedListener struct {
+	dsn          string
+	useCase      usecase.ChangeFeedUseCase
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewChangeFeedListener(
+	dsn string,
+	useCase usecase.ChangeFeedUseCase,
+	pollInterval time.Duration,
+	batchSize int,
+) *ChangeFeedListener {
+	return &ChangeFeedListener{
+		dsn:          dsn,
+		useCase:      useCase,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+	}
+}
+
+// Run listens and publishes changes until ctx is cancelled.
+func (w *ChangeFeedListener) Run(ctx context.Context) {
+	listener := pq.NewListener(w.dsn, listenRetryMinDelay, listenRetryMaxDelay, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Change feed listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if !w.listen(ctx, listener) {
+		log.Printf("Change feed listener stopped")
+		return
+	}
+
+	log.Printf("✓ Change feed listener started")
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Change feed listener stopped")
+			return
+		// A nil notification means the connection was re-established and
+		// notifications may have been missed; draining covers both cases.
+		case <-listener.Notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// listen subscribes to the change feed channel, retrying with exponential
+// backoff while the database is unavailable. It returns false if ctx is
+// cancelled first.
+func (w *ChangeFeedListener) listen(ctx context.Context, listener *pq.Listener) bool {
+	delay := listenRetryMinDelay
+	for {
+		err := listener.Listen(changeFeedChannel)
+		if err == nil {
+			return true
+		}
+		log.Printf("Failed to listen on %s, retrying in %v: %v", changeFeedChannel, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > listenRetryMaxDelay {
+			delay = listenRetryMaxDelay
+		}
+	}
+}
+
+func (w *ChangeFeedListener) drain(ctx context.Context) {
+	for {
+		handled, err := w.useCase.PublishPendingChanges(ctx, w.batchSize)
+		if err != nil {
+			log.Printf("Change feed error: %v", err)
+			return
+		}
+		if handled > 0 {
+			log.Printf("✓ Published events for %d external transaction changes", handled)
+		}
+		if handled < w.batchSize {
+			return
+		}
+	}
+}
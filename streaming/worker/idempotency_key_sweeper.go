@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"go-api-streaming/domain/repository"
+	"log"
+	"time"
+)
+
+// IdempotencyKeySweeper deletes expired idempotency keys. Expired keys are
+// already ignored when a request is made, so sweeping only keeps the table
+// small. Rows are locked with SKIP LOCKED, so several instances can run the
+// sweeper at once.
+type IdempotencyKeySweeper struct {
+	keyRepo   repository.IdempotencyKeyRepository
+	interval  time.Duration
+	batchSize int
+}
+
+func NewIdempotencyKeySweeper(
+	keyRepo repository.IdempotencyKeyRepository,
+	interval time.Duration,
+	batchSize int,
+) *IdempotencyKeySweeper {
+	return &IdempotencyKeySweeper{
+		keyRepo:   keyRepo,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run sweeps expired keys until ctx is cancelled.
+func (w *IdempotencyKeySweeper) Run(ctx context.Context) {
+	log.Printf("✓ Idempotency key sweeper started")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := w.keyRepo.DeleteExpired(ctx, time.Now(), w.batchSize)
+		if err != nil {
+			log.Printf("Idempotency key sweeper error: %v", err)
+		}
+
+		// Keep going while full batches are deleted.
+		if err == nil && deleted == w.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Idempotency key sweeper stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"go-api-streaming/domain/events"
+	"go-api-streaming/domain/repository"
+	"go-api-streaming/infrastructure/messaging"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// outboxPublisher is the part of the RabbitMQ client the relay uses.
+type outboxPublisher interface {
+	Publish(exchangeName, routingKey string, message interface{}, opts messaging.PublishOptions) error
+}
+
+// OutboxRelay publishes pending outbox events to RabbitMQ and marks them as
+// sent. Rows are locked with SKIP LOCKED, so several instances can run the
+// relay at once. Delivery is at-least-once: an event published right before
+// a crash is sent again on the next run.
+//
+// Events of the same aggregate are published in order. An event that fails
+// to publish holds back the later events of its aggregate until it goes out,
+// but not those of other aggregates.
+type OutboxRelay struct {
+	outboxRepo   repository.OutboxRepository
+	txManager    repository.TxManager
+	rabbitmq     outboxPublisher
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewOutboxRelay(
+	outboxRepo repository.OutboxRepository,
+	txManager repository.TxManager,
+	rabbitmq *messaging.RabbitMQClient,
+	pollInterval time.Duration,
+	batchSize int,
+) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepo:   outboxRepo,
+		txManager:    txManager,
+		rabbitmq:     rabbitmq,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+	}
+}
+
+// Run relays events until ctx is cancelled.
+func (w *OutboxRelay) Run(ctx context.Context) {
+	log.Printf("✓ Outbox relay started")
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		published, err := w.relayBatch(ctx)
+		if err != nil {
+			log.Printf("Outbox relay error: %v", err)
+		}
+
+		// Keep draining while full batches go out.
+		if err == nil && published == w.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	published := 0
+
+	err := w.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		pending, err := w.outboxRepo.LockPending(ctx, w.batchSize)
+		if err != nil {
+			return err
+		}
+
+		// Aggregates with an event that failed in this batch.
+		held := make(map[uuid.UUID]bool)
+		for _, event := range pending {
+			if held[event.AggregateID] {
+				continue
+			}
+
+			opts := messaging.PublishOptions{
+				MessageID:     event.ID.String(),
+				Type:          event.EventType,
+				CorrelationID: event.CorrelationID,
+				ContentType:   events.ContentType,
+				Timestamp:     event.CreatedAt,
+			}
+
+			if err := w.rabbitmq.Publish(event.Exchange, event.RoutingKey, event.Payload, opts); err != nil {
+				// Hold back the aggregate's later events so they keep their
+				// order, and carry on with the other aggregates.
+				log.Printf("Failed to relay outbox event %s (%s): %v", event.ID, event.EventType, err)
+				if err := w.outboxRepo.MarkFailed(ctx, event.ID, err.Error()); err != nil {
+					return err
+				}
+				held[event.AggregateID] = true
+				continue
+			}
+
+			if err := w.outboxRepo.MarkPublished(ctx, event.ID); err != nil {
+				return err
+			}
+			published++
+		}
+
+		return nil
+	})
+
+	return published, err
+}
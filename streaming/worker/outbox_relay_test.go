@@ -0,0 +1,154 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"go-api-streaming/domain/entity"
+	"go-api-streaming/infrastructure/messaging"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRelayBatch(t *testing.T) {
+	orderA, orderB, orderC := uuid.New(), uuid.New(), uuid.New()
+	event := func(aggregateID uuid.UUID, eventType string) *entity.OutboxEvent {
+		return &entity.OutboxEvent{ID: uuid.New(), AggregateID: aggregateID, EventType: eventType}
+	}
+	pending := []*entity.OutboxEvent{
+		event(orderA, "a1"),
+		event(orderB, "b1"),
+		event(orderA, "a2"),
+		event(orderC, "c1"),
+		event(orderB, "b2"),
+		event(orderC, "c2"),
+	}
+
+	repo := &fakeOutboxRepo{pending: pending}
+	publisher := &fakePublisher{fail: map[string]bool{"b1": true}}
+	relay := &OutboxRelay{outboxRepo: repo, txManager: fakeTxManager{}, rabbitmq: publisher, batchSize: len(pending)}
+
+	published, err := relay.relayBatch(context.Background())
+	if err != nil {
+		t.Fatalf("relayBatch error = %v", err)
+	}
+	if published != 4 {
+		t.Errorf("relayBatch = %d, want 4", published)
+	}
+
+	// b1 fails, so b2 waits behind it while the other aggregates go out in
+	// order.
+	wantAttempted := []string{"a1", "b1", "a2", "c1", "c2"}
+	if !equalStrings(publisher.attempted, wantAttempted) {
+		t.Errorf("attempted %v, want %v", publisher.attempted, wantAttempted)
+	}
+	wantPublished := []string{"a1", "a2", "c1", "c2"}
+	if got := repo.typesOf(repo.published); !equalStrings(got, wantPublished) {
+		t.Errorf("marked published %v, want %v", got, wantPublished)
+	}
+	if got := repo.typesOf(repo.failed); !equalStrings(got, []string{"b1"}) {
+		t.Errorf("marked failed %v, want [b1]", got)
+	}
+
+	// Once b1 goes through, b2 follows it.
+	publisher.fail = nil
+	repo.pending = []*entity.OutboxEvent{pending[1], pending[4]}
+	repo.published = nil
+	if _, err := relay.relayBatch(context.Background()); err != nil {
+		t.Fatalf("relayBatch error = %v", err)
+	}
+	if got := repo.typesOf(repo.published); !equalStrings(got, []string{"b1", "b2"}) {
+		t.Errorf("marked published %v, want [b1 b2]", got)
+	}
+}
+
+func TestRelayBatchRepositoryError(t *testing.T) {
+	repo := &fakeOutboxRepo{
+		pending:   []*entity.OutboxEvent{{ID: uuid.New(), AggregateID: uuid.New(), EventType: "a1"}},
+		failedErr: errors.New("connection reset"),
+	}
+	publisher := &fakePublisher{fail: map[string]bool{"a1": true}}
+	relay := &OutboxRelay{outboxRepo: repo, txManager: fakeTxManager{}, rabbitmq: publisher, batchSize: 1}
+
+	if _, err := relay.relayBatch(context.Background()); !errors.Is(err, repo.failedErr) {
+		t.Errorf("relayBatch error = %v, want %v", err, repo.failedErr)
+	}
+}
+
+type fakeTxManager struct{}
+
+func (fakeTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeOutboxRepo struct {
+	pending   []*entity.OutboxEvent
+	published []uuid.UUID
+	failed    []uuid.UUID
+	failedErr error
+}
+
+func (r *fakeOutboxRepo) Create(ctx context.Context, event *entity.OutboxEvent) error {
+	r.pending = append(r.pending, event)
+	return nil
+}
+
+func (r *fakeOutboxRepo) LockPending(ctx context.Context, limit int) ([]*entity.OutboxEvent, error) {
+	if len(r.pending) > limit {
+		return r.pending[:limit], nil
+	}
+	return r.pending, nil
+}
+
+func (r *fakeOutboxRepo) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	r.published = append(r.published, id)
+	return nil
+}
+
+func (r *fakeOutboxRepo) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	if r.failedErr != nil {
+		return r.failedErr
+	}
+	r.failed = append(r.failed, id)
+	return nil
+}
+
+// typesOf returns the event types of the pending events with the given IDs.
+func (r *fakeOutboxRepo) typesOf(ids []uuid.UUID) []string {
+	var types []string
+	for _, id := range ids {
+		for _, event := range r.pending {
+			if event.ID == id {
+				types = append(types, event.EventType)
+			}
+		}
+	}
+	return types
+}
+
+// fakePublisher records the message types it is asked to publish and fails
+// those in fail.
+type fakePublisher struct {
+	fail      map[string]bool
+	attempted []string
+}
+
+func (p *fakePublisher) Publish(exchangeName, routingKey string, message interface{}, opts messaging.PublishOptions) error {
+	p.attempted = append(p.attempted, opts.Type)
+	if p.fail[opts.Type] {
+		return messaging.ErrPublishNacked
+	}
+	return nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"go-api-streaming/usecase"
+	"log"
+	"time"
+)
+
+// PendingTransactionExpirer moves transactions that stayed pending for too
+// long to expired. Rows are locked with SKIP LOCKED, so several instances can
+// run the expirer at once.
+type PendingTransactionExpirer struct {
+	useCase   usecase.TransactionUseCase
+	interval  time.Duration
+	batchSize int
+}
+
+func NewPendingTransactionExpirer(
+	useCase usecase.TransactionUseCase,
+	interval time.Duration,
+	batchSize int,
+) *PendingTransactionExpirer {
+	return &PendingTransactionExpirer{
+		useCase:   useCase,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run expires stale pending transactions until ctx is cancelled.
+func (w *PendingTransactionExpirer) Run(ctx context.Context) {
+	log.Printf("✓ Pending transaction expirer started")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := w.useCase.ExpirePendingTransactions(ctx, w.batchSize)
+		if err != nil {
+			log.Printf("Pending transaction expirer error: %v", err)
+		}
+
+		// Keep going while full batches are expired.
+		if err == nil && expired == w.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Pending transaction expirer stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"go-api-streaming/usecase"
+	"log"
+	"time"
+)
+
+// Scheduler creates the transactions of due schedule occurrences. Schedules
+// are locked with SKIP LOCKED, so several instances can run the scheduler at
+// once.
+type Scheduler struct {
+	useCase      usecase.ScheduleUseCase
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewScheduler(
+	useCase usecase.ScheduleUseCase,
+	pollInterval time.Duration,
+	batchSize int,
+) *Scheduler {
+	return &Scheduler{
+		useCase:      useCase,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+	}
+}
+
+// Run runs due schedules until ctx is cancelled.
+func (w *Scheduler) Run(ctx context.Context) {
+	log.Printf("✓ Scheduler started")
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		handled, err := w.useCase.RunDueSchedules(ctx, w.batchSize)
+		if err != nil {
+			log.Printf("Scheduler error: %v", err)
+		}
+
+		// Keep going while full batches are due.
+		if err == nil && handled == w.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"go-api-streaming/usecase"
+	"log"
+	"time"
+)
+
+// WebhookDispatcher sends due webhook deliveries. Deliveries are locked with
+// SKIP LOCKED, so several instances can run the dispatcher at once.
+type WebhookDispatcher struct {
+	useCase      usecase.WebhookUseCase
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewWebhookDispatcher(
+	useCase usecase.WebhookUseCase,
+	pollInterval time.Duration,
+	batchSize int,
+) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		useCase:      useCase,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+	}
+}
+
+// Run dispatches deliveries until ctx is cancelled.
+func (w *WebhookDispatcher) Run(ctx context.Context) {
+	log.Printf("✓ Webhook dispatcher started")
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		attempted, err := w.useCase.DeliverDue(ctx, w.batchSize)
+		if err != nil {
+			log.Printf("Webhook dispatcher error: %v", err)
+		}
+
+		// Keep draining while full batches go out.
+		if err == nil && attempted == w.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}